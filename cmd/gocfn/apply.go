@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/plan"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	applyCommand      = kingpin.Command("apply", "Executes the change set of a plan created by the plan command, unless the stack has been updated since.")
+	applyPlanFile     = applyCommand.Arg("plan-file", "The path to the plan file.").Required().ExistingFile()
+	applyTemplateFile = applyCommand.Flag("template-file", "The path to the template, verified to be unchanged since the plan was created.").ExistingFile()
+	applyStream       = applyCommand.Flag("stream", "Stream stack events during creation or update process.").Bool()
+	applyEventsFile   = applyCommand.Flag("events-file", "The path to the file where streamed stack events are written as newline delimited JSON.").String()
+	applyStreamFormat = applyCommand.Flag("stream-format", "The format of streamed stack events.").Default("text").Enum("text", "json")
+	applyColor        = applyCommand.Flag("color", "Colour-code streamed stack events, auto enables colours when stderr is a terminal.").Default("auto").Enum("auto", "always", "never")
+)
+
+func apply(sess client.ConfigProvider) {
+	p, err := plan.Read(afero.NewOsFs(), aws.StringValue(applyPlanFile))
+	if err != nil {
+		logger.WithError(err).Error("error while running apply command")
+		exiter(exitCodeError)
+		return
+	}
+
+	eventSink, closeEventSink, err := newEventSink(*applyStream, *applyStreamFormat, *applyColor, *applyEventsFile)
+	if err != nil {
+		logger.WithError(err).Error("error while running apply command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := newStreamingCfn(sess, eventSink)
+
+	stack, err := cfn.Apply(p, aws.StringValue(applyTemplateFile))
+	closeEventSink()
+
+	if err != nil {
+		logger.WithError(err).Error("error while running apply command")
+
+		if report, ok := errors.Cause(err).(*failure.Report); ok {
+			jsonOutWriter.Write(report)
+		}
+
+		exiter(exitCode(err))
+		return
+	}
+
+	jsonOutWriter.Write(stack)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/pkg/errors"
+)
+
+var (
+	changeSetCommand = kingpin.Command("changeset", "Manages change sets created earlier, e.g. with --no-execute-changeset.")
+
+	changeSetExecuteCommand       = changeSetCommand.Command("execute", "Executes the change set and waits for the stack to be created or updated.")
+	changeSetExecuteStackName     = changeSetExecuteCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	changeSetExecuteChangeSetName = changeSetExecuteCommand.Arg("change-set-name", "The name or ARN of the change set.").Required().String()
+	changeSetExecuteStream        = changeSetExecuteCommand.Flag("stream", "Stream stack events during creation or update process.").Bool()
+	changeSetExecuteEventsFile    = changeSetExecuteCommand.Flag("events-file", "The path to the file where streamed stack events are written as newline delimited JSON.").String()
+	changeSetExecuteStreamFormat  = changeSetExecuteCommand.Flag("stream-format", "The format of streamed stack events.").Default("text").Enum("text", "json")
+	changeSetExecuteColor         = changeSetExecuteCommand.Flag("color", "Colour-code streamed stack events, auto enables colours when stderr is a terminal.").Default("auto").Enum("auto", "always", "never")
+
+	changeSetDeleteCommand       = changeSetCommand.Command("delete", "Deletes the change set.")
+	changeSetDeleteStackName     = changeSetDeleteCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	changeSetDeleteChangeSetName = changeSetDeleteCommand.Arg("change-set-name", "The name or ARN of the change set.").Required().String()
+
+	changeSetDescribeCommand         = changeSetCommand.Command("describe", "Describes the change set.")
+	changeSetDescribeStackName       = changeSetDescribeCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	changeSetDescribeChangeSetName   = changeSetDescribeCommand.Arg("change-set-name", "The name or ARN of the change set.").Required().String()
+	changeSetDescribeChangeSetFormat = changeSetDescribeCommand.Flag("changeset-format", "The format of the change set output.").Default("table").Enum("json", "table")
+	changeSetDescribeColor           = changeSetDescribeCommand.Flag("color", "Colour-code change set table, auto enables colours when stdout is a terminal.").Default("auto").Enum("auto", "always", "never")
+
+	changeSetListCommand   = changeSetCommand.Command("list", "Lists change sets of the stack.")
+	changeSetListStackName = changeSetListCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+)
+
+func changeSetExecute(sess client.ConfigProvider) {
+	eventSink, closeEventSink, err := newEventSink(*changeSetExecuteStream, *changeSetExecuteStreamFormat, *changeSetExecuteColor, *changeSetExecuteEventsFile)
+	if err != nil {
+		logger.WithError(err).Error("error while running changeset execute command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := newStreamingCfn(sess, eventSink)
+
+	stack, err := cfn.ExecuteChangeSet(aws.StringValue(changeSetExecuteStackName), aws.StringValue(changeSetExecuteChangeSetName))
+	closeEventSink()
+
+	if err != nil {
+		logger.WithError(err).Error("error while running changeset execute command")
+
+		if report, ok := errors.Cause(err).(*failure.Report); ok {
+			jsonOutWriter.Write(report)
+		}
+
+		exiter(exitCode(err))
+		return
+	}
+
+	jsonOutWriter.Write(stack)
+}
+
+func changeSetDelete(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	err := cfn.DeleteChangeSet(aws.StringValue(changeSetDeleteStackName), aws.StringValue(changeSetDeleteChangeSetName))
+	if err != nil {
+		logger.WithError(err).Error("error while running changeset delete command")
+		exiter(exitCodeError)
+		return
+	}
+
+	logger.WithField("stackName", *changeSetDeleteStackName).WithField("changeSetName", *changeSetDeleteChangeSetName).Info("change set has been deleted")
+}
+
+func changeSetDescribe(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	changeSet, err := cfn.DescribeChangeSet(aws.StringValue(changeSetDescribeStackName), aws.StringValue(changeSetDescribeChangeSetName))
+	if err != nil {
+		logger.WithError(err).Error("error while running changeset describe command")
+		exiter(exitCodeError)
+		return
+	}
+
+	newChangeSetWriter(*changeSetDescribeChangeSetFormat, *changeSetDescribeColor).Write(changeSet)
+}
+
+func changeSetList(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	summaries, err := cfn.ListChangeSets(aws.StringValue(changeSetListStackName))
+	if err != nil {
+		logger.WithError(err).Error("error while running changeset list command")
+		exiter(exitCodeError)
+		return
+	}
+
+	jsonOutWriter.Write(summaries)
+}
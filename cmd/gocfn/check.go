@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
+	"github.com/spf13/afero"
+)
+
+var (
+	checkCommand       = kingpin.Command("check", "Evaluates rules of the rule file against the template and, when change set name is given, its change set.")
+	checkRulesFile     = checkCommand.Flag("rules", "The path to the YAML or JSON rule file.").Required().ExistingFile()
+	checkTemplateFile  = checkCommand.Flag("template-file", "The path where your AWS CloudFormation template is located.").Required().ExistingFile()
+	checkStackName     = checkCommand.Flag("name", "The name of the AWS CloudFormation stack the change set belongs to.").String()
+	checkChangeSetName = checkCommand.Flag("change-set-name", "The name or ARN of the change set checked against change rules.").String()
+	checkLabels        = checkCommand.Flag("label", "A label available to rules as $labels, e.g. iam-approved.").Strings()
+)
+
+func check(sess client.ConfigProvider) {
+	ruleSet, err := rules.Load(afero.NewOsFs(), aws.StringValue(checkRulesFile))
+	if err != nil {
+		logger.WithError(err).Error("error while running check command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := cfn.New(sess, logger, false)
+
+	violations, err := cfn.Check(&rules.CheckParams{
+		RuleSet:       ruleSet,
+		TemplateFile:  aws.StringValue(checkTemplateFile),
+		StackName:     aws.StringValue(checkStackName),
+		ChangeSetName: aws.StringValue(checkChangeSetName),
+		Labels:        *checkLabels,
+	})
+	if err != nil {
+		logger.WithError(err).Error("error while running check command")
+		exiter(exitCodeError)
+		return
+	}
+
+	jsonOutWriter.Write(&rules.Report{Violations: violations})
+
+	if len(violations) > 0 {
+		exiter(exitCodePolicyViolation)
+	}
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/alecthomas/kingpin"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/b-b3rn4rd/gocfn/pkg/approver"
 	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
 	"github.com/b-b3rn4rd/gocfn/pkg/cli"
 	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
-	"github.com/b-b3rn4rd/gocfn/pkg/uploader"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
+	"github.com/b-b3rn4rd/gocfn/pkg/notifier"
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/reporter"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 )
 
@@ -25,37 +34,112 @@ var (
 	deployParameterOverrides   = cli.CFNParameters(deployCommand.Flag("parameter-overrides", "A list of parameter structures that specify input parameters for your stack template."))
 	deployCapabilities         = deployCommand.Flag("capabilities", "A list of capabilities that you must specify before AWS Cloudformation can create certain stacks.").Enums("CAPABILITY_IAM", "CAPABILITY_NAMED_IAM")
 	deployNoExecuteChangeset   = deployCommand.Flag("no-execute-changeset", "Indicates whether to execute the change set. Specify this flag if you want to view your stack changes before executing").Bool()
+	deployChangeSetFormat      = deployCommand.Flag("changeset-format", "The format of the change set output when --no-execute-changeset is given.").Default("json").Enum("json", "table")
+	deployTemplateDiff         = deployCommand.Flag("template-diff", "Include the diff of the template against the deployed template in the change set output when --no-execute-changeset is given.").Bool()
 	deployRoleArn              = deployCommand.Flag("role-arn", "The Amazon Resource Name (ARN) of an AWS Identity and Access Management (IAM) role").String()
 	deployNotificationArns     = deployCommand.Flag("notification-arns", "The Amazon Resource Name (ARN) of an AWS Identity and Access Management (IAM) role.").Strings()
 	deployFailOnEmptyChangeset = deployCommand.Flag("fail-on-empty-changeset", "Specify if the CLI should return a non-zero exit code if there are no changes to be made to the stack").Bool()
+	deployDetailedExitCode     = deployCommand.Flag("detailed-exitcode", "Exit with 0 when there are no changes and with 2 when the change set contains changes, requires --no-execute-changeset.").Bool()
 	deployTags                 = cli.CFNTags(deployCommand.Flag("tags", "A list of tags to associate with the stack that is created or updated."))
 	deployForceDeploy          = deployCommand.Flag("force-deploy", "Force CloudFormation stack deployment if it's in CREATE_FAILED state.").Bool()
 	deployStream               = deployCommand.Flag("stream", "Stream stack events during creation or update process.").Bool()
+	deployEventsFile           = deployCommand.Flag("events-file", "The path to the file where streamed stack events are written as newline delimited JSON.").String()
+	deployStreamFormat         = deployCommand.Flag("stream-format", "The format of streamed stack events.").Default("text").Enum("text", "json")
+	deployColor                = deployCommand.Flag("color", "Colour-code streamed stack events and change set table, auto enables colours when the output is a terminal.").Default("auto").Enum("auto", "always", "never")
+	deployWaitForStable        = deployCommand.Flag("wait-for-stable", "Wait for an in-progress stack operation to finish before creating a change set.").Bool()
+	deployWaitForStableTimeout = deployCommand.Flag("wait-for-stable-timeout", "The maximum time to wait for the stack to become stable.").Default("30m").Duration()
+	deployPruneChangeSets      = deployCommand.Flag("prune-changesets", "Delete change sets created by gocfn which are older than the given number of days, 0 keeps them.").Default("0").Int()
+	deployConfirm              = deployCommand.Flag("confirm", "Display the change set and ask for approval before executing it, rejected change set is deleted.").Bool()
+	deployYes                  = deployCommand.Flag("yes", "Execute the change set without asking for approval when --confirm is given.").Bool()
+	deployDenyReplacement      = cli.List(deployCommand.Flag("deny-replacement", "A comma separated list of resource types, e.g. AWS::RDS::DBInstance, which must not be replaced by the change set."))
+	deployDenyRemoval          = deployCommand.Flag("deny-removal", "Fail if the change set removes any resource.").Bool()
+	deployRulesFile            = deployCommand.Flag("rules", "The path to the YAML or JSON rule file evaluated against the template and the change set before it's executed.").ExistingFile()
+	deployLabels               = deployCommand.Flag("label", "A label available to rules as $labels, e.g. iam-approved.").Strings()
+	deployJUnitReport          = deployCommand.Flag("junit-report", "The path to the file where the command writes JUnit XML report of the deployment.").String()
+	deployTimings              = deployCommand.Flag("timings", "Display how long each resource took once the change set has been executed.").Bool()
+	deployTimingsFormat        = deployCommand.Flag("timings-format", "The format of displayed timings.").Default("table").Enum("table", "json")
+	deployNotifyURL            = deployCommand.Flag("notify-url", "The webhook URL notified when the deployment starts, its change set is created, and it succeeds or fails.").String()
+	deployNotifyTemplate       = deployCommand.Flag("notify-template", "The format of webhook notifications.").Default("generic").Enum("generic", "slack")
+	deployNotifyTimeout        = deployCommand.Flag("notify-timeout", "The time limit of a single webhook request.").Default("10s").Duration()
+	deployNotifyRetries        = deployCommand.Flag("notify-retries", "The maximum number of retries of failed webhook requests.").Default("3").Int()
 )
 
 func deploy(sess client.ConfigProvider) {
-	s3Svc := s3.New(sess)
-
-	var s3Uploader uploader.Uploaderiface
-
-	cfn := cfn.New(sess, logger, *deployStream)
-
-	if *deployS3Bucket != "" {
-		uSvc := s3manager.NewUploaderWithClient(s3Svc)
-		s3Uploader = uploader.New(
-			s3Svc,
-			uSvc,
-			logger,
-			deployS3Bucket,
-			deployS3Prefix,
-			deployKmsKeyID,
-			deployForceUpload,
-			afero.NewOsFs(),
+	if err := validateDeployFlags(os.Stdin); err != nil {
+		logger.WithError(err).Error("error while running deploy command")
+		exiter(exitCodeError)
+		return
+	}
+
+	var ntfr notifier.Notifieriface
+
+	if *deployNotifyURL != "" {
+		template := notifier.GenericTemplate
+		if *deployNotifyTemplate == "slack" {
+			template = notifier.SlackTemplate
+		}
+
+		ntfr = notifier.New(*deployNotifyURL, logger,
+			notifier.WithTemplate(template),
+			notifier.Timeout(*deployNotifyTimeout),
+			notifier.MaxRetries(*deployNotifyRetries),
 		)
 	}
 
+	options := []func(c *cfn.Cfn){cfn.Notifier(ntfr)}
+
+	if len(*deployDenyReplacement) > 0 || *deployDenyRemoval {
+		options = append(options, cfn.Guard(&guard.Policy{
+			DenyReplacement: *deployDenyReplacement,
+			DenyRemoval:     *deployDenyRemoval,
+		}))
+	}
+
+	if *deployRulesFile != "" {
+		ruleSet, err := rules.Load(afero.NewOsFs(), *deployRulesFile)
+		if err != nil {
+			logger.WithError(err).Error("error while running deploy command")
+			exiter(exitCodeError)
+			return
+		}
+
+		template, err := packager.New(logger, afero.NewOsFs()).Open(*deployTemplateFile)
+		if err != nil {
+			logger.WithError(err).Error("error while running deploy command")
+			exiter(exitCodeError)
+			return
+		}
+
+		options = append(options, cfn.Guard(&rules.Guard{
+			RuleSet:  ruleSet,
+			Template: template,
+			Labels:   *deployLabels,
+		}))
+	}
+
+	if *deployConfirm && !*deployYes {
+		options = append(options, cfn.Approver(approver.New(os.Stdin, os.Stderr, newChangeSetFormatter("table", *deployColor, os.Stderr))))
+	}
+
+	if *deployJUnitReport != "" {
+		options = append(options, cfn.Reporter(reporter.NewJUnit(*deployJUnitReport, logger, afero.NewOsFs())))
+	}
+
+	if *deployTimings {
+		options = append(options, cfn.Reporter(reporter.NewTimings(newTimingsWriter(os.Stderr, *deployTimingsFormat), 10)))
+	}
+
+	eventSink, closeEventSink, err := newEventSink(*deployStream, *deployStreamFormat, *deployColor, *deployEventsFile)
+	if err != nil {
+		logger.WithError(err).Error("error while running deploy command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := newStreamingCfn(sess, eventSink).With(options...)
+
 	body, err := cfn.Deploy(&deployer.DeployParams{
-		S3Uploader:           s3Uploader,
+		S3Uploader:           newS3Uploader(sess, deployS3Bucket, deployS3Prefix, deployKmsKeyID, deployForceUpload),
 		StackName:            aws.StringValue(deployStackName),
 		TemplateFile:         aws.StringValue(deployTemplateFile),
 		Parameters:           *deployParameterOverrides,
@@ -66,15 +150,50 @@ func deploy(sess client.ConfigProvider) {
 		FailOnEmptyChangeset: aws.BoolValue(deployFailOnEmptyChangeset),
 		Tags:                 *deployTags,
 		ForceDeploy:          aws.BoolValue(deployForceDeploy),
+		WaitForStable:        aws.BoolValue(deployWaitForStable),
+		WaitForStableTimeout: *deployWaitForStableTimeout,
+		PruneChangeSets:      time.Duration(*deployPruneChangeSets) * 24 * time.Hour,
+		TemplateDiff:         aws.BoolValue(deployTemplateDiff),
 	})
+	closeEventSink()
+
 	if err != nil {
 		logger.WithError(err).Error("error while running deploy command")
-		exiter(1)
+
+		switch report := errors.Cause(err).(type) {
+		case *failure.Report:
+			jsonOutWriter.Write(report)
+		case *guard.Report:
+			jsonOutWriter.Write(report)
+		case *rules.Report:
+			jsonOutWriter.Write(report)
+		}
+
+		exiter(exitCode(err))
 		return
 	}
 
 	switch body.(type) {
-	case *cloudformation.Stack, *cloudformation.DescribeChangeSetOutput:
+	case *preview.ChangeSet:
+		newChangeSetWriter(*deployChangeSetFormat, *deployColor).Write(body)
+
+		if *deployDetailedExitCode {
+			exiter(exitCodeChanges)
+		}
+	case *cloudformation.Stack:
 		jsonOutWriter.Write(body)
 	}
 }
+
+// validateDeployFlags rejects flag combinations which can't work, approval is asked on stdin
+func validateDeployFlags(stdin *os.File) error {
+	if *deployDetailedExitCode && !*deployNoExecuteChangeset {
+		return errors.New("--detailed-exitcode requires --no-execute-changeset")
+	}
+
+	if *deployConfirm && !*deployYes && !isTerminal(stdin) {
+		return errors.New("stdin is not a terminal, pass --yes to execute the change set without asking")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTerminalRejectsDevNull(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	assert.NoError(t, err)
+	defer devNull.Close()
+
+	assert.False(t, isTerminal(devNull))
+}
+
+func TestValidateDeployFlags(t *testing.T) {
+	tests := map[string]struct {
+		confirm            bool
+		yes                bool
+		detailedExitCode   bool
+		noExecuteChangeset bool
+		terminal           bool
+		expectedErr        string
+	}{
+		"confirm is allowed when stdin is a terminal": {
+			confirm:  true,
+			terminal: true,
+		},
+		"confirm is rejected when stdin isn't a terminal": {
+			confirm:     true,
+			expectedErr: "stdin is not a terminal, pass --yes to execute the change set without asking",
+		},
+		"confirm with yes doesn't need a terminal": {
+			confirm: true,
+			yes:     true,
+		},
+		"detailed exit code is rejected when change set is executed": {
+			detailedExitCode: true,
+			expectedErr:      "--detailed-exitcode requires --no-execute-changeset",
+		},
+		"detailed exit code is allowed without executing change set": {
+			detailedExitCode:   true,
+			noExecuteChangeset: true,
+		},
+	}
+
+	defer func(isTerminalFunc func(f *os.File) bool) {
+		isTerminal = isTerminalFunc
+		*deployConfirm, *deployYes, *deployDetailedExitCode, *deployNoExecuteChangeset = false, false, false, false
+	}(isTerminal)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			isTerminal = func(f *os.File) bool {
+				assert.Equal(t, os.Stdin, f)
+				return test.terminal
+			}
+
+			*deployConfirm = test.confirm
+			*deployYes = test.yes
+			*deployDetailedExitCode = test.detailedExitCode
+			*deployNoExecuteChangeset = test.noExecuteChangeset
+
+			err := validateDeployFlags(os.Stdin)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
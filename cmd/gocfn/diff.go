@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+var (
+	diffCommand      = kingpin.Command("diff", "Compares parameters, resources and outputs of the template with the template of the deployed stack.")
+	diffStackName    = diffCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	diffTemplateFile = diffCommand.Flag("template-file", "The path where your AWS CloudFormation template is located.").Required().ExistingFile()
+	diffFormat       = diffCommand.Flag("format", "The format of the diff.").Default("unified").Enum("unified", "json")
+	diffColor        = diffCommand.Flag("color", "Colour-code the unified diff, auto enables colours when stdout is a terminal.").Default("auto").Enum("auto", "always", "never")
+)
+
+func diff(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	changes, err := cfn.TemplateDiff(aws.StringValue(diffStackName), aws.StringValue(diffTemplateFile))
+	if err != nil {
+		logger.WithError(err).Error("error while running diff command")
+		exiter(exitCode(err))
+		return
+	}
+
+	if len(changes) == 0 {
+		logger.WithField("stackName", *diffStackName).Info("template has no differences from the deployed template")
+	}
+
+	if *diffFormat == "json" {
+		jsonOutWriter.Write(changes)
+		return
+	}
+
+	colored := *diffColor == "always" || (*diffColor == "auto" && isTerminal(os.Stdout))
+
+	writer.New(os.Stdout, templatediff.UnifiedFormatter(colored)).Write(changes)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/cli"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+var (
+	eventsCommand      = kingpin.Command("events", "Displays past stack events matching the given filters in chronological order.")
+	eventsStackName    = eventsCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	eventsSince        = cli.Since(eventsCommand.Flag("since", "Only display events newer than RFC3339 timestamp or duration ago, e.g. 2h."))
+	eventsStatus       = eventsCommand.Flag("status", "Only display events with resource status containing it, e.g. FAILED.").String()
+	eventsResourceType = eventsCommand.Flag("resource-type", "Only display events of the resource type, e.g. AWS::Lambda::Function.").String()
+	eventsLogicalID    = eventsCommand.Flag("logical-id", "Only display events of the resource with logical ID.").String()
+	eventsOutput       = eventsCommand.Flag("output", "The format of displayed stack events.").Default("table").Enum("table", "json", "ndjson")
+	eventsColor        = eventsCommand.Flag("color", "Colour-code table output, auto enables colours when stdout is a terminal.").Default("auto").Enum("auto", "always", "never")
+)
+
+func events(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	stackEvents, err := cfn.Events(aws.StringValue(eventsStackName), &streamer.EventFilter{
+		Since:             time.Time(*eventsSince),
+		ResourceStatus:    aws.StringValue(eventsStatus),
+		ResourceType:      aws.StringValue(eventsResourceType),
+		LogicalResourceId: aws.StringValue(eventsLogicalID),
+	})
+	if err != nil {
+		logger.WithError(err).Error("error while running events command")
+		exiter(exitCodeError)
+		return
+	}
+
+	switch *eventsOutput {
+	case "json":
+		jsonOutWriter.Write(stackEvents)
+	case "ndjson":
+		wr := writer.New(os.Stdout, writer.NDJSONFormatter)
+		for _, e := range stackEvents {
+			wr.Write(e)
+		}
+	default:
+		colored := *eventsColor == "always" || (*eventsColor == "auto" && isTerminal(os.Stdout))
+		wr := writer.New(os.Stdout, streamer.TextFormatter(colored))
+		for _, e := range stackEvents {
+			wr.Write(e)
+		}
+	}
+}
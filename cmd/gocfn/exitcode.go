@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
+	"github.com/pkg/errors"
+)
+
+// exit codes shared by all commands, keep in sync with the exit codes table in README
+const (
+	// exitCodeOK the command has completed successfully
+	exitCodeOK = 0
+	// exitCodeError any error without a dedicated exit code, e.g. AWS error or invalid template
+	exitCodeError = 1
+	// exitCodeChanges the change set contains changes, used only with deploy --detailed-exitcode
+	exitCodeChanges = 2
+	// exitCodeStackFailed the stack operation has failed or rolled back
+	exitCodeStackFailed = 3
+	// exitCodePolicyViolation the change set or template violates the deploy policy or rules
+	exitCodePolicyViolation = 4
+	// exitCodeTimeout the stack hasn't become stable in time
+	exitCodeTimeout = 5
+)
+
+// exitCode maps error returned by a command to its exit code
+func exitCode(err error) int {
+	switch errors.Cause(err).(type) {
+	case *failure.Report:
+		return exitCodeStackFailed
+	case *guard.Report, *rules.Report:
+		return exitCodePolicyViolation
+	case *deployer.StableTimeoutError:
+		return exitCodeTimeout
+	}
+
+	return exitCodeError
+}
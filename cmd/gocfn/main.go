@@ -4,18 +4,38 @@ import (
 	"os"
 
 	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/retryer"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/b-b3rn4rd/gocfn/pkg/uploader"
 	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/term"
 )
 
 var (
-	version       = "master"
-	debug         = kingpin.Flag("debug", "Enable debug logging.").Short('d').Bool()
-	logger        = logrus.New()
-	jsonOutWriter = writer.New(os.Stdout, writer.JSONFormatter)
-	strOutWriter  = writer.New(os.Stdout, writer.PlainFormatter)
-	exiter        = os.Exit
+	version         = "master"
+	debug           = kingpin.Flag("debug", "Enable debug logging.").Short('d').Bool()
+	maxRetries      = kingpin.Flag("max-retries", "The maximum number of retries for throttled or failed AWS requests.").Default("10").Int()
+	retryMinDelay   = kingpin.Flag("retry-min-delay", "The initial delay before retrying throttled or failed AWS requests, doubled on each retry.").Default("500ms").Duration()
+	retryMaxDelay   = kingpin.Flag("retry-max-delay", "The maximum delay between retries of throttled or failed AWS requests.").Default("30s").Duration()
+	pollInterval    = kingpin.Flag("poll-interval", "The interval between polls for stack events while new events keep coming.").Default("2s").Duration()
+	maxPollInterval = kingpin.Flag("max-poll-interval", "The maximum interval between polls for stack events.").Default("15s").Duration()
+	pollBackoff     = kingpin.Flag("poll-backoff", "The factor the poll interval is multiplied by after each poll without new stack events.").Default("2").Float64()
+	logger          = logrus.New()
+	jsonOutWriter   = writer.New(os.Stdout, writer.JSONFormatter)
+	strOutWriter    = writer.New(os.Stdout, writer.PlainFormatter)
+	exiter          = os.Exit
 )
 
 func main() {
@@ -29,7 +49,20 @@ func main() {
 
 	logger.Formatter = &logrus.JSONFormatter{}
 
+	if err := retryer.Validate(*maxRetries, *retryMinDelay, *retryMaxDelay); err != nil {
+		logger.WithError(errors.Wrap(err, "invalid retry settings")).Error("error while running gocfn")
+		exiter(exitCodeError)
+		return
+	}
+
+	if err := streamer.ValidatePolling(*pollInterval, *maxPollInterval, *pollBackoff); err != nil {
+		logger.WithError(errors.Wrap(err, "invalid polling settings")).Error("error while running gocfn")
+		exiter(exitCodeError)
+		return
+	}
+
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		Config:            *request.WithRetryer(aws.NewConfig(), retryer.New(logger, *maxRetries, *retryMinDelay, *retryMaxDelay)),
 		SharedConfigState: session.SharedConfigEnable,
 	}))
 
@@ -38,5 +71,125 @@ func main() {
 		deploy(sess)
 	case "package":
 		packaage(sess)
+	case "tail":
+		tail(sess)
+	case "events":
+		events(sess)
+	case "timings":
+		timingsCmd(sess)
+	case "plan":
+		planCmd(sess)
+	case "apply":
+		apply(sess)
+	case "check":
+		check(sess)
+	case "diff":
+		diff(sess)
+	case "changeset execute":
+		changeSetExecute(sess)
+	case "changeset delete":
+		changeSetDelete(sess)
+	case "changeset describe":
+		changeSetDescribe(sess)
+	case "changeset list":
+		changeSetList(sess)
+	}
+}
+
+// newS3Uploader creates uploader of templates and artifacts, nil is returned when bucket isn't given
+func newS3Uploader(sess client.ConfigProvider, bucket *string, prefix *string, kmsKeyID *string, forceUpload *bool) uploader.Uploaderiface {
+	if aws.StringValue(bucket) == "" {
+		return nil
+	}
+
+	s3Svc := s3.New(sess)
+
+	return uploader.New(
+		s3Svc,
+		s3manager.NewUploaderWithClient(s3Svc),
+		logger,
+		bucket,
+		prefix,
+		kmsKeyID,
+		forceUpload,
+		afero.NewOsFs(),
+	)
+}
+
+// newChangeSetWriter creates stdout writer for change sets in the given format
+func newChangeSetWriter(format string, color string) *writer.StringWriter {
+	return writer.New(os.Stdout, newChangeSetFormatter(format, color, os.Stdout))
+}
+
+// newChangeSetFormatter creates formatter of change sets in the given format, colours are enabled in auto mode when out is a terminal
+func newChangeSetFormatter(format string, color string, out *os.File) writer.FormatFunc {
+	if format == "table" {
+		colored := color == "always" || (color == "auto" && isTerminal(out))
+		return preview.TableFormatter(colored)
 	}
+
+	return writer.JSONFormatter
+}
+
+// newStreamingCfn creates Cfn streaming stack events into eventSink with the global poll flags,
+// events aren't streamed when eventSink is nil
+func newStreamingCfn(sess client.ConfigProvider, eventSink streamer.EventSink) *cfn.Cfn {
+	stmr := streamer.New(cloudformation.New(sess), logger,
+		streamer.PollInterval(*pollInterval),
+		streamer.MaxPollInterval(*maxPollInterval),
+		streamer.PollBackoff(*pollBackoff),
+	)
+
+	return cfn.New(sess, logger, eventSink != nil).With(cfn.Streamer(stmr), cfn.EventSink(eventSink))
+}
+
+// newEventSink creates sink for stack events streamed to stderr and/or into the events file,
+// nil is returned when neither is requested. The returned function closes the events file
+// and must be called once streaming has finished
+func newEventSink(stream bool, format string, color string, eventsFile string) (streamer.EventSink, func(), error) {
+	sinks := streamer.MultiSink{}
+	closeSink := func() {}
+
+	if stream {
+		sinks = append(sinks, newEventWriter(format, color))
+	}
+
+	if eventsFile != "" {
+		fileSink, err := streamer.NewFileSink(afero.NewOsFs(), eventsFile)
+		if err != nil {
+			return nil, closeSink, errors.Wrap(err, "error while creating events file")
+		}
+
+		sinks = append(sinks, fileSink)
+		closeSink = func() {
+			if err := fileSink.Close(); err != nil {
+				logger.WithError(err).Warn("error while closing events file")
+			}
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, closeSink, nil
+	case 1:
+		return sinks[0], closeSink, nil
+	}
+
+	return sinks, closeSink, nil
+}
+
+// newEventWriter creates stderr writer for stack events in the given format
+func newEventWriter(format string, color string) *writer.StringWriter {
+	if format == "json" {
+		return writer.New(os.Stderr, writer.JSONFormatter)
+	}
+
+	colored := color == "always" || (color == "auto" && isTerminal(os.Stderr))
+
+	return writer.New(os.Stderr, streamer.TextFormatter(colored))
+}
+
+// isTerminal checks if f is a terminal rather than any character device such as /dev/null
+var isTerminal = func(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
@@ -30,7 +30,7 @@ func packaage(sess client.ConfigProvider) {
 
 	var s3Uploader uploader.Uploaderiface
 
-	cfn := cfn.New(sess, logger, *deployStream)
+	cfn := cfn.New(sess, logger, false)
 
 	if *packageS3Bucket != "" {
 		uSvc := s3manager.NewUploaderWithClient(s3Svc)
@@ -52,7 +52,7 @@ func packaage(sess client.ConfigProvider) {
 	})
 	if err != nil {
 		logger.WithError(err).Error("error while running package command")
-		exiter(1)
+		exiter(exitCodeError)
 		return
 	}
 
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/cli"
+	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
+	"github.com/b-b3rn4rd/gocfn/pkg/plan"
+	"github.com/spf13/afero"
+)
+
+var (
+	planCommand              = kingpin.Command("plan", "Creates a change set without executing it and writes a plan file, which can be applied later with the apply command.")
+	planTemplateFile         = planCommand.Flag("template-file", "The path where your AWS CloudFormation template is located.").Required().ExistingFile()
+	planStackName            = planCommand.Flag("name", "The name of the AWS CloudFormation stack you're deploying to.").Required().String()
+	planOut                  = planCommand.Flag("out", "The path to the file where the command writes the plan.").Required().String()
+	planS3Bucket             = planCommand.Flag("s3-bucket", "The name of the S3 bucket where this command uploads your CloudFormation template.").String()
+	planForceUpload          = planCommand.Flag("force-upload", "Indicates whether to override existing files in the S3 bucket.").Bool()
+	planS3Prefix             = planCommand.Flag("s3-prefix", "A prefix name that the command adds to the artifacts name when it uploads them to the S3 bucket.").String()
+	planKmsKeyID             = planCommand.Flag("kms-key-id", "The ID of an AWS KMS key that the command uses to encrypt artifacts that are at rest in the S3 bucket.").String()
+	planParameterOverrides   = cli.CFNParameters(planCommand.Flag("parameter-overrides", "A list of parameter structures that specify input parameters for your stack template."))
+	planCapabilities         = planCommand.Flag("capabilities", "A list of capabilities that you must specify before AWS Cloudformation can create certain stacks.").Enums("CAPABILITY_IAM", "CAPABILITY_NAMED_IAM")
+	planRoleArn              = planCommand.Flag("role-arn", "The Amazon Resource Name (ARN) of an AWS Identity and Access Management (IAM) role").String()
+	planNotificationArns     = planCommand.Flag("notification-arns", "The Amazon Resource Name (ARN) of an AWS Identity and Access Management (IAM) role.").Strings()
+	planFailOnEmptyChangeset = planCommand.Flag("fail-on-empty-changeset", "Specify if the CLI should return a non-zero exit code if there are no changes to be made to the stack").Bool()
+	planTags                 = cli.CFNTags(planCommand.Flag("tags", "A list of tags to associate with the stack that is created or updated."))
+	planForceDeploy          = planCommand.Flag("force-deploy", "Force CloudFormation stack deployment if it's in CREATE_FAILED state.").Bool()
+	planWaitForStable        = planCommand.Flag("wait-for-stable", "Wait for an in-progress stack operation to finish before creating a change set.").Bool()
+	planWaitForStableTimeout = planCommand.Flag("wait-for-stable-timeout", "The maximum time to wait for the stack to become stable.").Default("30m").Duration()
+	planPruneChangeSets      = planCommand.Flag("prune-changesets", "Delete change sets created by gocfn which are older than the given number of days, 0 keeps them.").Default("0").Int()
+	planChangeSetFormat      = planCommand.Flag("changeset-format", "The format of the change set output.").Default("table").Enum("json", "table")
+	planColor                = planCommand.Flag("color", "Colour-code change set table, auto enables colours when stdout is a terminal.").Default("auto").Enum("auto", "always", "never")
+)
+
+func planCmd(sess client.ConfigProvider) {
+	cfn := cfn.New(sess, logger, false)
+
+	p, changeSet, err := cfn.Plan(&deployer.DeployParams{
+		S3Uploader:           newS3Uploader(sess, planS3Bucket, planS3Prefix, planKmsKeyID, planForceUpload),
+		StackName:            aws.StringValue(planStackName),
+		TemplateFile:         aws.StringValue(planTemplateFile),
+		Parameters:           *planParameterOverrides,
+		Capabilities:         *planCapabilities,
+		RoleArn:              aws.StringValue(planRoleArn),
+		NotificationArns:     *planNotificationArns,
+		FailOnEmptyChangeset: aws.BoolValue(planFailOnEmptyChangeset),
+		Tags:                 *planTags,
+		ForceDeploy:          aws.BoolValue(planForceDeploy),
+		WaitForStable:        aws.BoolValue(planWaitForStable),
+		WaitForStableTimeout: *planWaitForStableTimeout,
+		PruneChangeSets:      time.Duration(*planPruneChangeSets) * 24 * time.Hour,
+	})
+	if err != nil {
+		logger.WithError(err).Error("error while running plan command")
+		exiter(exitCode(err))
+		return
+	}
+
+	newChangeSetWriter(*planChangeSetFormat, *planColor).Write(changeSet)
+
+	if p == nil {
+		logger.WithField("stackName", *planStackName).Info("change set contains no changes, plan is not written")
+		return
+	}
+
+	err = plan.Write(afero.NewOsFs(), aws.StringValue(planOut), p)
+	if err != nil {
+		logger.WithError(err).Error("error while running plan command")
+		exiter(exitCodeError)
+		return
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/pkg/errors"
+)
+
+var (
+	tailCommand      = kingpin.Command("tail", "Streams events of an already running stack operation until the stack reaches a terminal status.")
+	tailStackName    = tailCommand.Flag("name", "The name of the AWS CloudFormation stack you're following.").Required().String()
+	tailTimeout      = tailCommand.Flag("timeout", "The maximum time to wait for the stack operation to finish, 0 waits indefinitely.").Default("0s").Duration()
+	tailEventsFile   = tailCommand.Flag("events-file", "The path to the file where streamed stack events are written as newline delimited JSON.").String()
+	tailStreamFormat = tailCommand.Flag("stream-format", "The format of streamed stack events.").Default("text").Enum("text", "json")
+	tailColor        = tailCommand.Flag("color", "Colour-code streamed stack events, auto enables colours when stderr is a terminal.").Default("auto").Enum("auto", "always", "never")
+)
+
+func tail(sess client.ConfigProvider) {
+	eventSink, closeEventSink, err := newEventSink(true, *tailStreamFormat, *tailColor, *tailEventsFile)
+	if err != nil {
+		logger.WithError(err).Error("error while running tail command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := newStreamingCfn(sess, eventSink)
+
+	stack, err := cfn.Tail(aws.StringValue(tailStackName), *tailTimeout)
+	closeEventSink()
+
+	if err != nil {
+		logger.WithError(err).Error("error while running tail command")
+
+		if report, ok := errors.Cause(err).(*failure.Report); ok {
+			jsonOutWriter.Write(report)
+		}
+
+		exiter(exitCode(err))
+		return
+	}
+
+	jsonOutWriter.Write(stack)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
+	"github.com/b-b3rn4rd/gocfn/pkg/timings"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/pkg/errors"
+)
+
+var (
+	timingsCommand            = kingpin.Command("timings", "Displays how long each resource of a past stack operation took, its critical path and the slowest resources.")
+	timingsStackName          = timingsCommand.Flag("name", "The name of the AWS CloudFormation stack.").Required().String()
+	timingsClientRequestToken = timingsCommand.Flag("client-request-token", "The token of the analysed operation, defaults to the latest operation of the stack.").String()
+	timingsSlowest            = timingsCommand.Flag("slowest", "The number of the slowest resources to display.").Default("10").Int()
+	timingsOutput             = timingsCommand.Flag("output", "The format of displayed timings.").Default("table").Enum("table", "json")
+)
+
+func timingsCmd(sess client.ConfigProvider) {
+	if *timingsSlowest < 0 {
+		logger.WithError(errors.Errorf("--slowest must not be negative, got %d", *timingsSlowest)).Error("error while running timings command")
+		exiter(exitCodeError)
+		return
+	}
+
+	cfn := cfn.New(sess, logger, false)
+
+	stackEvents, err := cfn.OperationEvents(aws.StringValue(timingsStackName), aws.StringValue(timingsClientRequestToken))
+	if err != nil {
+		logger.WithError(err).Error("error while running timings command")
+		exiter(exitCodeError)
+		return
+	}
+
+	newTimingsWriter(os.Stdout, *timingsOutput).Write(timings.Analyze(aws.StringValue(timingsStackName), stackEvents, *timingsSlowest))
+}
+
+// newTimingsWriter creates writer for timings report in the given format
+func newTimingsWriter(f *os.File, format string) *writer.StringWriter {
+	if format == "json" {
+		return writer.New(f, writer.JSONFormatter)
+	}
+
+	return writer.New(f, timings.TableFormatter)
+}
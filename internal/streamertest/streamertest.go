@@ -0,0 +1,28 @@
+// Package streamertest provides stack events for tests of packages consuming streamed events
+package streamertest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// Timestamp time events are offset from
+var Timestamp = time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+// StackEvent creates event of the resource in the stack, its EventId is derived from the stack,
+// the resource and the status, Timestamp is offset by offset
+func StackEvent(stackID string, logicalID string, physicalID string, resourceType string, status string, reason string, offset time.Duration) *cloudformation.StackEvent {
+	return &cloudformation.StackEvent{
+		EventId:              aws.String(fmt.Sprintf("%s-%s-%s", stackID, logicalID, status)),
+		StackId:              aws.String(stackID),
+		LogicalResourceId:    aws.String(logicalID),
+		PhysicalResourceId:   aws.String(physicalID),
+		ResourceType:         aws.String(resourceType),
+		ResourceStatus:       aws.String(status),
+		ResourceStatusReason: aws.String(reason),
+		Timestamp:            aws.Time(Timestamp.Add(offset)),
+	}
+}
@@ -0,0 +1,51 @@
+package approver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/pkg/errors"
+)
+
+// Approveriface decides whether the change set is executed
+type Approveriface interface {
+	Approve(changeSet *preview.ChangeSet) (bool, error)
+}
+
+// Prompt renders the change set and asks the user to approve it, anything but y or yes rejects it
+type Prompt struct {
+	in     *bufio.Reader
+	out    io.Writer
+	format writer.FormatFunc
+}
+
+// New creates a prompt reading answers from in, the change set is rendered into out with format
+func New(in io.Reader, out io.Writer, format writer.FormatFunc) *Prompt {
+	return &Prompt{
+		in:     bufio.NewReader(in),
+		out:    out,
+		format: format,
+	}
+}
+
+// Approve renders the change set with its parameter and tag differences and waits for the answer
+func (p *Prompt) Approve(changeSet *preview.ChangeSet) (bool, error) {
+	p.format(p.out, changeSet)
+	fmt.Fprint(p.out, "Execute this change set? [y/N] ")
+
+	answer, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Wrap(err, "error while reading answer")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+
+	return false, nil
+}
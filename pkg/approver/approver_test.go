@@ -0,0 +1,57 @@
+package approver_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/approver"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprove(t *testing.T) {
+	tests := map[string]struct {
+		answer   string
+		approved bool
+	}{
+		"y approves the change set": {
+			answer:   "y\n",
+			approved: true,
+		},
+		"yes in any case approves the change set": {
+			answer:   " YES \n",
+			approved: true,
+		},
+		"n rejects the change set": {
+			answer: "n\n",
+		},
+		"empty answer rejects the change set": {
+			answer: "\n",
+		},
+		"closed input rejects the change set": {
+			answer: "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			prompt := approver.New(strings.NewReader(test.answer), out, writer.PlainFormatter)
+
+			approved, err := prompt.Approve(&preview.ChangeSet{
+				DescribeChangeSetOutput: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetName: aws.String("cfn-1"),
+				},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.approved, approved)
+			assert.Contains(t, out.String(), "cfn-1")
+			assert.True(t, strings.HasSuffix(out.String(), "Execute this change set? [y/N] "))
+		})
+	}
+}
@@ -1,14 +1,25 @@
 package cfn
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/approver"
 	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
+	"github.com/b-b3rn4rd/gocfn/pkg/notifier"
 	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/plan"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/reporter"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
 	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -19,10 +30,17 @@ type Params interface {
 }
 
 type Cfn struct {
-	dplr   deployer.Deployeriface
-	pckgr  packager.Packageriface
-	stmr   streamer.Streameriface
-	logger *logrus.Logger
+	dplr      deployer.Deployeriface
+	pckgr     packager.Packageriface
+	stmr      streamer.Streameriface
+	ntfr      notifier.Notifieriface
+	apprvr    approver.Approveriface
+	guards    []guard.Guardiface
+	reporters []reporter.Reporteriface
+	appFs     afero.Fs
+	eventSink streamer.EventSink
+	stream    bool
+	logger    *logrus.Logger
 }
 
 func Deployer(dplr deployer.Deployeriface) func(cfn *Cfn) {
@@ -43,43 +61,149 @@ func Streamer(stmr streamer.Streameriface) func(cfn *Cfn) {
 	}
 }
 
-func Logger(logger *logrus.Logger) func(cfn *Cfn) {
+// Stream enables streaming of stack events with the streamer
+func Stream(stream bool) func(cfn *Cfn) {
 	return func(cfn *Cfn) {
-		cfn.logger = logger
+		cfn.stream = stream
 	}
 }
 
-func New(sess client.ConfigProvider, logger *logrus.Logger, streamRequired bool) *Cfn {
-	cfnSvc := cloudformation.New(sess)
+// EventSink sets the sink receiving streamed stack events instead of the deployer's default one
+func EventSink(eventSink streamer.EventSink) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.eventSink = eventSink
+	}
+}
 
-	dplr := deployer.New(cfnSvc, logger)
-	pckgr := packager.New(logger, afero.NewOsFs())
+// Notifier sends notifications at each phase of the deployment
+func Notifier(ntfr notifier.Notifieriface) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.ntfr = ntfr
+	}
+}
 
-	var stmr streamer.Streameriface
+// Approver asks for approval of the change set before it's executed
+func Approver(apprvr approver.Approveriface) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.apprvr = apprvr
+	}
+}
 
-	if streamRequired {
-		stmr = streamer.New(cfnSvc, logger)
+// Guard adds guard checking the change set before it's executed
+func Guard(grd guard.Guardiface) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.guards = append(cfn.guards, grd)
 	}
+}
+
+// Fs sets the filesystem templates are read from
+func Fs(appFs afero.Fs) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.appFs = appFs
+	}
+}
+
+// Reporter adds reporter receiving the outcome of the deployment
+func Reporter(rprtr reporter.Reporteriface) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.reporters = append(cfn.reporters, rprtr)
+	}
+}
+
+func Logger(logger *logrus.Logger) func(cfn *Cfn) {
+	return func(cfn *Cfn) {
+		cfn.logger = logger
+	}
+}
+
+// New creates a new Cfn struct, stack events are streamed when streamRequired is true
+func New(sess client.ConfigProvider, logger *logrus.Logger, streamRequired bool) *Cfn {
+	cfnSvc := cloudformation.New(sess)
 
 	return &Cfn{
-		dplr:   dplr,
-		pckgr:  pckgr,
-		stmr:   stmr,
+		dplr:   deployer.New(cfnSvc, logger),
+		pckgr:  packager.New(logger, afero.NewOsFs()),
+		stmr:   streamer.New(cfnSvc, logger),
+		appFs:  afero.NewOsFs(),
+		stream: streamRequired,
 		logger: logger,
 	}
 }
 
 func NewWithOptions(options ...func(cfn *Cfn)) *Cfn {
-	gocfn := &Cfn{}
+	return (&Cfn{}).With(options...)
+}
 
+// With applies options to the existing Cfn struct
+func (c *Cfn) With(options ...func(cfn *Cfn)) *Cfn {
 	for _, option := range options {
-		option(gocfn)
+		option(c)
 	}
 
-	return gocfn
+	return c
 }
 
 func (c *Cfn) Deploy(deployParams *deployer.DeployParams) (interface{}, error) {
+	c.notify(&notifier.Notification{
+		Phase:     notifier.PhaseStarted,
+		StackName: deployParams.StackName,
+	})
+
+	op := &reporter.Operation{
+		StackName: deployParams.StackName,
+		StartTime: time.Now().UTC(),
+	}
+
+	body, err := c.deploy(deployParams, op)
+
+	op.EndTime = time.Now().UTC()
+	op.Err = err
+	c.report(op)
+
+	if err != nil {
+		n := &notifier.Notification{
+			Phase:     notifier.PhaseFailed,
+			StackName: deployParams.StackName,
+			Err:       err.Error(),
+		}
+
+		if report, ok := errors.Cause(err).(*failure.Report); ok {
+			n.StackStatus = report.StackStatus
+		}
+
+		c.notify(n)
+
+		return body, err
+	}
+
+	stack, ok := body.(*cloudformation.Stack)
+	if !ok {
+		// the change set hasn't been executed, it has already been notified about with PhaseChangeSet
+		return body, nil
+	}
+
+	n := &notifier.Notification{
+		Phase:       notifier.PhaseSucceeded,
+		StackName:   deployParams.StackName,
+		StackStatus: aws.StringValue(stack.StackStatus),
+	}
+
+	if !op.IsExecuted() {
+		n.Phase = notifier.PhaseNoChanges
+	}
+
+	c.notify(n)
+
+	return body, nil
+}
+
+// deploy creates and executes the change set, op is filled in as the deployment progresses
+func (c *Cfn) deploy(deployParams *deployer.DeployParams, op *reporter.Operation) (interface{}, error) {
+	if err := c.waitForStable(deployParams); err != nil {
+		return "", err
+	}
+
+	c.pruneChangeSets(deployParams)
 
 	changeSet := c.dplr.CreateChangeSet(deployParams)
 
@@ -94,11 +218,10 @@ func (c *Cfn) Deploy(deployParams *deployer.DeployParams) (interface{}, error) {
 
 	changeSet.ChangeSet = changeSetResult.ChangeSet
 	changeSet.Err = changeSetResult.Err
+	op.ChangeSet = changeSet.ChangeSet
 
 	if changeSet.Err != nil {
-		isEmptyChangeSet := strings.Contains(changeSet.Err.Error(), "The submitted information didn't contain changes.")
-
-		if !deployParams.FailOnEmptyChangeset && isEmptyChangeSet {
+		if !deployParams.FailOnEmptyChangeset && isEmptyChangeSet(changeSet.Err) {
 			return c.dplr.DescribeStackUnsafe(aws.String(deployParams.StackName)), nil
 		}
 
@@ -106,32 +229,477 @@ func (c *Cfn) Deploy(deployParams *deployer.DeployParams) (interface{}, error) {
 
 	}
 
+	summary := preview.Summarize(changeSet.ChangeSet)
+
+	c.notify(&notifier.Notification{
+		Phase:       notifier.PhaseChangeSet,
+		StackName:   deployParams.StackName,
+		ChangeSetId: aws.StringValue(changeSet.ChangeSet.ChangeSetId),
+		Changes:     notifier.ChangeSetChanges(changeSet.ChangeSet),
+		Summary:     &summary,
+	})
+
+	// guards are evaluated even when the change set isn't executed, so that a denied change set fails its review
+	for _, grd := range c.guards {
+		if err := grd.Check(changeSet.ChangeSet); err != nil {
+			return "", errors.Wrap(err, "changeSet check error")
+		}
+	}
+
+	changeSetPreview := preview.Diff(changeSet.Stack, changeSet.ChangeSet, deployParams.Parameters, deployParams.Tags)
+
 	if deployParams.NoExecuteChangeset {
-		return changeSet.ChangeSet, nil
+		if deployParams.TemplateDiff {
+			templateChanges, err := c.templateDiff(deployParams.StackName, deployParams.TemplateFile, changeSet.Stack != nil)
+			if err != nil {
+				return "", err
+			}
+
+			changeSetPreview.TemplateChanges = templateChanges
+		}
+
+		return changeSetPreview, nil
+	}
+
+	if err := c.approve(aws.String(deployParams.StackName), changeSetPreview); err != nil {
+		return "", err
 	}
 
-	if c.stmr != nil {
-		seenStackEvents := c.stmr.DescribeStackEvents(aws.String(deployParams.StackName), nil)
-		if seenStackEvents.Err != nil {
-			return "", errors.Wrap(seenStackEvents.Err, "error while gathering stack events")
+	stack, err := c.execute(aws.String(deployParams.StackName), changeSet, op)
+	if err != nil {
+		return "", err
+	}
+
+	return stack, nil
+}
+
+// Plan creates the change set without executing it, the plan is nil when the change set contains no changes
+func (c *Cfn) Plan(deployParams *deployer.DeployParams) (*plan.Plan, *preview.ChangeSet, error) {
+	templateHash, err := plan.TemplateHash(c.appFs, deployParams.TemplateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.waitForStable(deployParams); err != nil {
+		return nil, nil, err
+	}
+
+	c.pruneChangeSets(deployParams)
+
+	changeSet := c.dplr.CreateChangeSet(deployParams)
+	if changeSet.Err != nil {
+		return nil, nil, errors.Wrap(changeSet.Err, "changeSet creation error")
+	}
+
+	changeSetResult := c.dplr.WaitForChangeSet(aws.String(deployParams.StackName), changeSet.ChangeSet.ChangeSetId)
+	if changeSetResult.Err != nil {
+		if !deployParams.FailOnEmptyChangeset && isEmptyChangeSet(changeSetResult.Err) {
+			return nil, preview.Diff(changeSet.Stack, changeSetResult.ChangeSet, deployParams.Parameters, deployParams.Tags), nil
+		}
+
+		return nil, nil, errors.Wrap(changeSetResult.Err, "changeSet creation error")
+	}
+
+	p := &plan.Plan{
+		Version:            plan.Version,
+		StackName:          deployParams.StackName,
+		StackId:            aws.StringValue(changeSetResult.ChangeSet.StackId),
+		ChangeSetId:        aws.StringValue(changeSetResult.ChangeSet.ChangeSetId),
+		ChangeSetType:      aws.StringValue(changeSet.ChangeSetType),
+		ClientRequestToken: aws.StringValue(changeSet.ClientRequestToken),
+		TemplateHash:       templateHash,
+		Parameters:         plan.RedactNoEcho(deployParams.Parameters, changeSetResult.ChangeSet.Parameters),
+		CreatedAt:          time.Now().UTC(),
+	}
+
+	// the stack is described before the change set is created, creating it doesn't update the stack
+	if p.ChangeSetType == cloudformation.ChangeSetTypeUpdate && changeSet.Stack != nil {
+		p.StackLastUpdatedTime = changeSet.Stack.LastUpdatedTime
+	}
+
+	return p, preview.Diff(changeSet.Stack, changeSetResult.ChangeSet, deployParams.Parameters, deployParams.Tags), nil
+}
+
+// Apply executes the change set of the plan, unless the stack has been updated since the plan was created.
+// Template file is checked against the plan unless templateFile is empty
+func (c *Cfn) Apply(p *plan.Plan, templateFile string) (*cloudformation.Stack, error) {
+	if templateFile != "" {
+		templateHash, err := plan.TemplateHash(c.appFs, templateFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if templateHash != p.TemplateHash {
+			return nil, fmt.Errorf("template %s has changed since the plan was created", templateFile)
 		}
+	}
 
-		changeSet.StackEvents = seenStackEvents.Records
+	changeSet := c.dplr.DescribeChangeSet(aws.String(p.StackName), aws.String(p.ChangeSetId))
+	if changeSet.Err != nil {
+		return nil, errors.Wrap(changeSet.Err, "error while describing change set of the plan")
 	}
 
-	err := c.dplr.ExecuteChangeset(aws.String(deployParams.StackName), changeSet.ChangeSet.ChangeSetId)
+	if status := aws.StringValue(changeSet.ChangeSet.ExecutionStatus); status != cloudformation.ExecutionStatusAvailable {
+		return nil, fmt.Errorf("change set of the plan can't be executed, its execution status is %s", status)
+	}
+
+	if p.ChangeSetType == cloudformation.ChangeSetTypeUpdate {
+		stack := c.dplr.DescribeStack(aws.String(p.StackName))
+		if stack.Err != nil {
+			return nil, errors.Wrap(stack.Err, "error while describing stack")
+		}
+
+		if aws.StringValue(stack.Stack.StackId) != p.StackId || !isSameTime(stack.Stack.LastUpdatedTime, p.StackLastUpdatedTime) {
+			return nil, fmt.Errorf("stack %s has been updated since the plan was created", p.StackName)
+		}
+	}
+
+	changeSet.ClientRequestToken = aws.String(p.ClientRequestToken)
+	changeSet.ChangeSetType = aws.String(p.ChangeSetType)
+
+	return c.execute(aws.String(p.StackName), changeSet, &reporter.Operation{})
+}
+
+// ExecuteChangeSet executes the change set created earlier, e.g. with --no-execute-changeset, and waits for the stack
+func (c *Cfn) ExecuteChangeSet(stackName string, changeSetName string) (*cloudformation.Stack, error) {
+	changeSet := c.dplr.ExistingChangeSet(aws.String(stackName), aws.String(changeSetName))
+	if changeSet.Err != nil {
+		return nil, errors.Wrap(changeSet.Err, "error while describing change set")
+	}
+
+	if status := aws.StringValue(changeSet.ChangeSet.ExecutionStatus); status != cloudformation.ExecutionStatusAvailable {
+		return nil, fmt.Errorf("change set %s can't be executed, its execution status is %s", changeSetName, status)
+	}
+
+	return c.execute(aws.String(stackName), changeSet, &reporter.Operation{})
+}
+
+// DescribeChangeSet describes the change set created earlier
+func (c *Cfn) DescribeChangeSet(stackName string, changeSetName string) (*cloudformation.DescribeChangeSetOutput, error) {
+	changeSet := c.dplr.DescribeChangeSet(aws.String(stackName), aws.String(changeSetName))
+	if changeSet.Err != nil {
+		return nil, errors.Wrap(changeSet.Err, "error while describing change set")
+	}
+
+	return changeSet.ChangeSet, nil
+}
+
+// DeleteChangeSet deletes the change set created earlier
+func (c *Cfn) DeleteChangeSet(stackName string, changeSetName string) error {
+	err := c.dplr.DeleteChangeSet(aws.String(stackName), aws.String(changeSetName))
+	if err != nil {
+		return errors.Wrap(err, "error while deleting change set")
+	}
+
+	return nil
+}
+
+// ListChangeSets lists change sets of the stack
+func (c *Cfn) ListChangeSets(stackName string) ([]*cloudformation.ChangeSetSummary, error) {
+	summaries, err := c.dplr.ListChangeSets(aws.String(stackName))
 	if err != nil {
-		return "", errors.Wrap(err, "changeSet execution error")
+		return nil, errors.Wrap(err, "error while listing change sets")
+	}
+
+	return summaries, nil
+}
+
+// waitForStable waits for an in-progress operation to finish when requested by deployParams
+func (c *Cfn) waitForStable(deployParams *deployer.DeployParams) error {
+	if !deployParams.WaitForStable {
+		return nil
+	}
+
+	stable := c.dplr.WaitForStable(aws.String(deployParams.StackName), deployParams.WaitForStableTimeout, c.streamer())
+	if stable.StreamErr != nil {
+		c.logger.WithError(stable.StreamErr).Warn("stack events streaming has stopped early")
 	}
 
-	res := c.dplr.WaitForExecute(aws.String(deployParams.StackName), changeSet, c.stmr)
+	if stable.Err != nil {
+		return errors.Wrap(stable.Err, "error while waiting for stack to become stable")
+	}
+
+	return nil
+}
+
+// pruneChangeSets deletes stale change sets when requested by deployParams, failing to prune doesn't fail the deployment
+func (c *Cfn) pruneChangeSets(deployParams *deployer.DeployParams) {
+	if deployParams.PruneChangeSets <= 0 {
+		return
+	}
+
+	pruned, err := c.dplr.PruneChangeSets(aws.String(deployParams.StackName), deployParams.PruneChangeSets)
+	if err != nil {
+		c.logger.WithError(err).Warn("error while pruning stale change sets")
+	}
+
+	for _, summary := range pruned {
+		c.logger.WithField("stackName", deployParams.StackName).WithField("changeSetName", aws.StringValue(summary.ChangeSetName)).Info("stale change set has been deleted")
+	}
+}
+
+// approve asks for approval of the change set when approver is set, rejected change set is deleted
+func (c *Cfn) approve(stackName *string, changeSet *preview.ChangeSet) error {
+	if c.apprvr == nil {
+		return nil
+	}
+
+	approved, err := c.apprvr.Approve(changeSet)
+	if err != nil {
+		return errors.Wrap(err, "error while approving change set")
+	}
+
+	if approved {
+		return nil
+	}
+
+	if err := c.dplr.DeleteChangeSet(stackName, changeSet.ChangeSetId); err != nil {
+		c.logger.WithError(err).Warn("error while deleting rejected change set")
+	}
+
+	return fmt.Errorf("change set %s has been rejected", aws.StringValue(changeSet.ChangeSetName))
+}
+
+// execute executes the change set and waits for the stack, op is filled in with the outcome
+func (c *Cfn) execute(stackName *string, changeSet *deployer.ChangeSetRecord, op *reporter.Operation) (*cloudformation.Stack, error) {
+	err := c.dplr.ExecuteChangeset(stackName, changeSet.ChangeSet.ChangeSetId, changeSet.ClientRequestToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "changeSet execution error")
+	}
+
+	op.ClientRequestToken = aws.StringValue(changeSet.ClientRequestToken)
+
+	res := c.dplr.WaitForExecute(stackName, changeSet, c.streamer())
+	if res.StreamErr != nil {
+		c.logger.WithError(res.StreamErr).Warn("stack events streaming has stopped early")
+	}
+
+	op.Stack = res.Stack
+
 	if res.Err != nil {
-		return "", errors.Wrap(res.Err, "changeSet execution error")
+		return nil, errors.Wrap(c.failureReport(stackName, res, changeSet.EventFilter()), "changeSet execution error")
 	}
 
 	return res.Stack, nil
 }
 
+// TemplateDiff compares the template file with the template of the deployed stack
+func (c *Cfn) TemplateDiff(stackName string, templateFile string) ([]*templatediff.Change, error) {
+	return c.templateDiff(stackName, templateFile, true)
+}
+
+// templateDiff compares the template file with the template of the stack, everything is added when it isn't deployed
+func (c *Cfn) templateDiff(stackName string, templateFile string, deployed bool) ([]*templatediff.Change, error) {
+	var deployedTemplate *packager.Template
+
+	if deployed {
+		body, err := c.dplr.GetTemplate(aws.String(stackName))
+		if err != nil {
+			return nil, err
+		}
+
+		if deployedTemplate, err = c.pckgr.Parse([]byte(body)); err != nil {
+			return nil, errors.Wrap(err, "error while parsing deployed template")
+		}
+	}
+
+	raw, err := afero.ReadFile(c.appFs, templateFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading template")
+	}
+
+	template, err := c.pckgr.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while parsing template")
+	}
+
+	return templatediff.Diff(deployedTemplate, template)
+}
+
+// Tail streams events of the stack's in-progress operation until the stack becomes stable,
+// failure report is returned when the operation has failed or rolled back
+func (c *Cfn) Tail(stackName string, timeout time.Duration) (*cloudformation.Stack, error) {
+	res := c.dplr.WaitForStable(aws.String(stackName), timeout, c.streamer())
+	if res.StreamErr != nil {
+		c.logger.WithError(res.StreamErr).Warn("stack events streaming has stopped early")
+	}
+
+	if res.Err != nil {
+		return nil, errors.Wrap(res.Err, "error while waiting for stack to become stable")
+	}
+
+	if res.Stack == nil {
+		return nil, fmt.Errorf("stack %s does not exist", stackName)
+	}
+
+	if failure.IsFailedStatus(aws.StringValue(res.Stack.StackStatus)) {
+		res.Err = fmt.Errorf("failed creating/updating stack, status: %s", aws.StringValue(res.Stack.StackStatus))
+		return res.Stack, c.failureReport(aws.String(stackName), res, streamer.OperationFilter(res.Stack))
+	}
+
+	return res.Stack, nil
+}
+
+// Events returns stack events matching filter in chronological order
+func (c *Cfn) Events(stackName string, filter *streamer.EventFilter) (streamer.StackEventList, error) {
+	res := c.stmr.DescribeStackEvents(aws.String(stackName), filter, nil)
+	if res.Err != nil {
+		return nil, errors.Wrap(res.Err, "error while gathering stack events")
+	}
+
+	return res.Records, nil
+}
+
+// OperationEvents returns events of the stack operation with clientRequestToken in chronological order,
+// events of the stack's latest operation are returned when clientRequestToken is empty
+func (c *Cfn) OperationEvents(stackName string, clientRequestToken string) (streamer.StackEventList, error) {
+	filter := &streamer.EventFilter{}
+
+	if clientRequestToken != "" {
+		filter.ClientRequestToken = aws.String(clientRequestToken)
+	} else {
+		stack := c.dplr.DescribeStack(aws.String(stackName))
+		if stack.Err != nil {
+			return nil, errors.Wrap(stack.Err, "error while describing stack")
+		}
+
+		filter = streamer.OperationFilter(stack.Stack)
+	}
+
+	return c.Events(stackName, filter)
+}
+
+// Check evaluates the rule set against the template and, when change set name is given, against the change set
+func (c *Cfn) Check(checkParams *rules.CheckParams) ([]*rules.Violation, error) {
+	template, err := c.pckgr.Open(checkParams.TemplateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeSet *cloudformation.DescribeChangeSetOutput
+
+	if checkParams.ChangeSetName != "" {
+		res := c.dplr.DescribeChangeSet(aws.String(checkParams.StackName), aws.String(checkParams.ChangeSetName))
+		if res.Err != nil {
+			return nil, errors.Wrap(res.Err, "error while describing change set")
+		}
+
+		changeSet = res.ChangeSet
+	}
+
+	violations, err := checkParams.RuleSet.Evaluate(template, changeSet, checkParams.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while evaluating rules")
+	}
+
+	return violations, nil
+}
+
+// failureReport finds root causes of the failed stack operation, filter narrows down events to the operation
+func (c *Cfn) failureReport(stackName *string, res *deployer.StackRecord, filter *streamer.EventFilter) error {
+	if c.stmr == nil || res.Stack == nil {
+		return res.Err
+	}
+
+	report := &failure.Report{
+		StackName:   aws.StringValue(res.Stack.StackName),
+		StackStatus: aws.StringValue(res.Stack.StackStatus),
+	}
+
+	operationEvents := c.stmr.DescribeStackEvents(stackName, filter, nil)
+	if operationEvents.Err != nil {
+		c.logger.WithError(operationEvents.Err).Warn("error while gathering stack events for failure report")
+		return report
+	}
+
+	failures, err := failure.RootCauses(operationEvents.Records, func(stackID *string) (streamer.StackEventList, error) {
+		nestedEvents := c.stmr.DescribeStackEvents(stackID, &streamer.EventFilter{Since: filter.Since}, nil)
+		return nestedEvents.Records, nestedEvents.Err
+	})
+
+	if err != nil {
+		c.logger.WithError(err).Warn("error while gathering nested stack events for failure report")
+	}
+
+	report.Failures = failures
+
+	return report
+}
+
+// report passes the outcome of the deployment to reporters, failing to report doesn't fail the deployment
+func (c *Cfn) report(op *reporter.Operation) {
+	if len(c.reporters) == 0 {
+		return
+	}
+
+	if op.IsExecuted() && c.stmr != nil {
+		filter := &streamer.EventFilter{
+			ClientRequestToken: aws.String(op.ClientRequestToken),
+			Since:              aws.TimeValue(op.ChangeSet.CreationTime),
+		}
+
+		events := c.stmr.DescribeStackEvents(aws.String(op.StackName), filter, nil)
+		if events.Err != nil {
+			c.logger.WithError(events.Err).Warn("error while gathering stack events for reporters")
+		}
+
+		op.Events = events.Records
+	}
+
+	for _, rprtr := range c.reporters {
+		if err := rprtr.Report(op); err != nil {
+			c.logger.WithError(err).Warn("error while reporting deployment")
+		}
+	}
+}
+
+// notify sends the notification, failing to notify doesn't fail the deployment
+func (c *Cfn) notify(n *notifier.Notification) {
+	if c.ntfr == nil {
+		return
+	}
+
+	n.Timestamp = time.Now().UTC()
+
+	if err := c.ntfr.Notify(n); err != nil {
+		c.logger.WithError(err).WithField("phase", n.Phase).Warn("error while sending deployment notification")
+	}
+}
+
+func isEmptyChangeSet(err error) bool {
+	return strings.Contains(err.Error(), "The submitted information didn't contain changes.")
+}
+
+func isSameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func (c *Cfn) streamer() streamer.Streameriface {
+	if !c.stream {
+		return nil
+	}
+
+	if c.eventSink != nil {
+		return &sinkStreamer{Streameriface: c.stmr, eventSink: c.eventSink}
+	}
+
+	return c.stmr
+}
+
+// sinkStreamer streams stack events into eventSink instead of the sink given by the deployer
+type sinkStreamer struct {
+	streamer.Streameriface
+	eventSink streamer.EventSink
+}
+
+func (s *sinkStreamer) StartStreaming(stackName *string, filter *streamer.EventFilter, _ streamer.EventSink, done <-chan bool) error {
+	return s.Streameriface.StartStreaming(stackName, filter, s.eventSink, done)
+}
+
 func (c *Cfn) Package(packageParams *packager.PackageParams) (string, error) {
 	template, err := c.pckgr.Export(packageParams)
 	if err != nil {
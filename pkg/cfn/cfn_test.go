@@ -2,26 +2,48 @@ package cfn_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/b-b3rn4rd/gocfn/pkg/cfn"
 	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
+	"github.com/b-b3rn4rd/gocfn/pkg/notifier"
 	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/plan"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/b-b3rn4rd/gocfn/pkg/reporter"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
 	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
 	"github.com/b-b3rn4rd/gocfn/pkg/uploader"
-	"github.com/b-b3rn4rd/gocfn/pkg/writer"
 	"github.com/pkg/errors"
 	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockedDeployer struct {
+	waitForStableResp       deployer.StackRecord
 	waitForChangeSetResp    deployer.ChangeSetRecord
 	waitForExecuteResp      deployer.StackRecord
 	executeChangesetErr     error
 	createChangeSetResp     deployer.ChangeSetRecord
 	describeStackUnsafeResp cloudformation.Stack
+	describeStackResp       deployer.StackRecord
+	describeChangeSetResp   deployer.ChangeSetRecord
+	existingChangeSetResp   deployer.ChangeSetRecord
+	deleteChangeSetErr      error
+	listChangeSetsResp      []*cloudformation.ChangeSetSummary
+	listChangeSetsErr       error
+	pruneChangeSetsResp     []*cloudformation.ChangeSetSummary
+	pruneChangeSetsErr      error
+	prunedStacks            *[]string
+	deletedChangeSets       *[]string
+	getTemplateResp         string
+	getTemplateErr          error
 }
 
 type mockerPackager struct {
@@ -32,6 +54,8 @@ type mockerPackager struct {
 	writeOutputErr error
 	marshallResp   []byte
 	marshallErr    error
+	parseResp      *packager.Template
+	parseErr       error
 }
 
 func (p mockerPackager) Export(packageParams *packager.PackageParams) (*packager.Template, error) {
@@ -42,6 +66,10 @@ func (p mockerPackager) Open(filename string) (*packager.Template, error) {
 	return p.openResp, p.opentErr
 }
 
+func (p mockerPackager) Parse(body []byte) (*packager.Template, error) {
+	return p.parseResp, p.parseErr
+}
+
 func (p mockerPackager) Marshall(filename string, template *packager.Template) ([]byte, error) {
 	return p.marshallResp, p.marshallErr
 }
@@ -54,12 +82,12 @@ type mockedStreamer struct {
 	describeStackEventsResp streamer.StackEventsRecord
 }
 
-func (s mockedStreamer) StartStreaming(stackName *string, seenEvents streamer.StackEvents, wr *writer.StringWriter, done <-chan bool) error {
+func (s mockedStreamer) StartStreaming(stackName *string, filter *streamer.EventFilter, sink streamer.EventSink, done <-chan bool) error {
 	<-done
 	return nil
 }
 
-func (s mockedStreamer) DescribeStackEvents(stackName *string, seenEvents streamer.StackEvents) (stackEvents *streamer.StackEventsRecord) {
+func (s mockedStreamer) DescribeStackEvents(stackName *string, filter *streamer.EventFilter, seenEvents streamer.StackEvents) (stackEvents *streamer.StackEventsRecord) {
 	return &s.describeStackEventsResp
 }
 
@@ -71,7 +99,7 @@ func (s mockedDeployer) WaitForExecute(stackName *string, changeSet *deployer.Ch
 	return &s.waitForExecuteResp
 }
 
-func (s mockedDeployer) ExecuteChangeset(stackName *string, changeSetID *string) error {
+func (s mockedDeployer) ExecuteChangeset(stackName *string, changeSetID *string, clientRequestToken *string) error {
 	return s.executeChangesetErr
 }
 
@@ -83,6 +111,46 @@ func (s mockedDeployer) DescribeStackUnsafe(stackName *string) *cloudformation.S
 	return &s.describeStackUnsafeResp
 }
 
+func (s mockedDeployer) DescribeStack(stackName *string) *deployer.StackRecord {
+	return &s.describeStackResp
+}
+
+func (s mockedDeployer) DescribeChangeSet(stackName *string, changeSetID *string) *deployer.ChangeSetRecord {
+	return &s.describeChangeSetResp
+}
+
+func (s mockedDeployer) ExistingChangeSet(stackName *string, changeSetName *string) *deployer.ChangeSetRecord {
+	return &s.existingChangeSetResp
+}
+
+func (s mockedDeployer) DeleteChangeSet(stackName *string, changeSetName *string) error {
+	if s.deletedChangeSets != nil {
+		*s.deletedChangeSets = append(*s.deletedChangeSets, *changeSetName)
+	}
+
+	return s.deleteChangeSetErr
+}
+
+func (s mockedDeployer) ListChangeSets(stackName *string) ([]*cloudformation.ChangeSetSummary, error) {
+	return s.listChangeSetsResp, s.listChangeSetsErr
+}
+
+func (s mockedDeployer) PruneChangeSets(stackName *string, olderThan time.Duration) ([]*cloudformation.ChangeSetSummary, error) {
+	if s.prunedStacks != nil {
+		*s.prunedStacks = append(*s.prunedStacks, *stackName)
+	}
+
+	return s.pruneChangeSetsResp, s.pruneChangeSetsErr
+}
+
+func (s mockedDeployer) GetTemplate(stackName *string) (string, error) {
+	return s.getTemplateResp, s.getTemplateErr
+}
+
+func (s mockedDeployer) WaitForStable(stackName *string, timeout time.Duration, stmr streamer.Streameriface) *deployer.StackRecord {
+	return &s.waitForStableResp
+}
+
 func TestDeploy(t *testing.T) {
 	tests := map[string]struct {
 		// mocks
@@ -102,11 +170,22 @@ func TestDeploy(t *testing.T) {
 		failOnEmptyChangeset *bool
 		tags                 []*cloudformation.Tag
 		forceDeploy          *bool
+		waitForStable        *bool
 
 		// output
 		expectedResp interface{}
 		expectedErr  error
 	}{
+		"deploy calls fatal error if stack did not become stable": {
+			waitForStable: aws.Bool(true),
+			dplr: mockedDeployer{
+				waitForStableResp: deployer.StackRecord{
+					Err: errors.New("stack is still in UPDATE_IN_PROGRESS"),
+				},
+			},
+			expectedResp: "",
+			expectedErr:  errors.New("stack is still in UPDATE_IN_PROGRESS"),
+		},
 		"deploy calls fatal error if CreateChangeSet produced an error": {
 			dplr: mockedDeployer{
 				createChangeSetResp: deployer.ChangeSetRecord{
@@ -194,32 +273,57 @@ func TestDeploy(t *testing.T) {
 					},
 				},
 			},
-			expectedResp: &cloudformation.DescribeChangeSetOutput{
-				StackId:     aws.String("hello"),
-				ChangeSetId: aws.String("1"),
+			expectedResp: &preview.ChangeSet{
+				DescribeChangeSetOutput: &cloudformation.DescribeChangeSetOutput{
+					StackId:     aws.String("hello"),
+					ChangeSetId: aws.String("1"),
+				},
+				ParameterChanges: []*preview.Difference{},
 			},
 		},
-		"deploy calls fatal error if ExecuteChangeset return an error": {
+		"deploy returns parameter and tag differences against the deployed stack if noExecuteChangeset is given": {
 			failOnEmptyChangeset: aws.Bool(false),
-			noExecuteChangeset:   aws.Bool(false),
+			noExecuteChangeset:   aws.Bool(true),
+			parameters: []*cloudformation.Parameter{
+				{ParameterKey: aws.String("InstanceType"), ParameterValue: aws.String("m5.large")},
+			},
+			tags: []*cloudformation.Tag{
+				{Key: aws.String("env"), Value: aws.String("prod")},
+			},
 			dplr: mockedDeployer{
 				createChangeSetResp: deployer.ChangeSetRecord{
 					ChangeSet: &cloudformation.DescribeChangeSetOutput{
 						ChangeSetId: aws.String("1"),
 					},
+					Stack: &cloudformation.Stack{
+						Parameters: []*cloudformation.Parameter{
+							{ParameterKey: aws.String("InstanceType"), ParameterValue: aws.String("t3.small")},
+						},
+						Tags: []*cloudformation.Tag{
+							{Key: aws.String("team"), Value: aws.String("platform")},
+						},
+					},
 				},
 				waitForChangeSetResp: deployer.ChangeSetRecord{
 					ChangeSet: &cloudformation.DescribeChangeSetOutput{
-						StackId:     aws.String("hello"),
 						ChangeSetId: aws.String("1"),
 					},
 				},
-				executeChangesetErr: errors.New("some error"),
 			},
-			expectedResp: "",
-			expectedErr:  errors.New("some error"),
+			expectedResp: &preview.ChangeSet{
+				DescribeChangeSetOutput: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("1"),
+				},
+				ParameterChanges: []*preview.Difference{
+					{Action: "Modify", Key: "InstanceType", OldValue: "t3.small", NewValue: "m5.large"},
+				},
+				TagChanges: []*preview.Difference{
+					{Action: "Add", Key: "env", NewValue: "prod"},
+					{Action: "Remove", Key: "team", OldValue: "platform"},
+				},
+			},
 		},
-		"deploy calls fatal error if DescribeStackEvents return an error": {
+		"deploy calls fatal error if ExecuteChangeset return an error": {
 			failOnEmptyChangeset: aws.Bool(false),
 			noExecuteChangeset:   aws.Bool(false),
 			dplr: mockedDeployer{
@@ -234,14 +338,10 @@ func TestDeploy(t *testing.T) {
 						ChangeSetId: aws.String("1"),
 					},
 				},
-			},
-			strmr: mockedStreamer{
-				describeStackEventsResp: streamer.StackEventsRecord{
-					Err: errors.New("error"),
-				},
+				executeChangesetErr: errors.New("some error"),
 			},
 			expectedResp: "",
-			expectedErr:  errors.New("error"),
+			expectedErr:  errors.New("some error"),
 		},
 		"deploy calls fatal error if WaitForExecute return an error": {
 			failOnEmptyChangeset: aws.Bool(false),
@@ -317,6 +417,7 @@ func TestDeploy(t *testing.T) {
 				FailOnEmptyChangeset: aws.BoolValue(test.failOnEmptyChangeset),
 				Tags:                 test.tags,
 				ForceDeploy:          aws.BoolValue(test.forceDeploy),
+				WaitForStable:        aws.BoolValue(test.waitForStable),
 			},
 			)
 
@@ -330,36 +431,1127 @@ func TestDeploy(t *testing.T) {
 	}
 }
 
-func TestPackage(t *testing.T) {
+func TestDeployFailureReport(t *testing.T) {
+	logger, _ := logrustest.NewNullLogger()
+	cfn := cfn.NewWithOptions(
+		cfn.Deployer(mockedDeployer{
+			createChangeSetResp: deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("1"),
+				},
+			},
+			waitForChangeSetResp: deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("1"),
+				},
+			},
+			waitForExecuteResp: deployer.StackRecord{
+				Stack: &cloudformation.Stack{
+					StackName:   aws.String("hello"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackComplete),
+				},
+				Err: errors.New("failed creating/updating stack"),
+			},
+		}),
+		cfn.Streamer(mockedStreamer{
+			describeStackEventsResp: streamer.StackEventsRecord{
+				Records: streamer.StackEventList{
+					{
+						EventId:              aws.String("1"),
+						StackId:              aws.String("hello"),
+						LogicalResourceId:    aws.String("Bucket"),
+						PhysicalResourceId:   aws.String("helloza"),
+						ResourceType:         aws.String("AWS::S3::Bucket"),
+						ResourceStatus:       aws.String(cloudformation.ResourceStatusUpdateFailed),
+						ResourceStatusReason: aws.String("helloza already exists"),
+					},
+				},
+			},
+		}),
+		cfn.Logger(logger))
+
+	_, err := cfn.Deploy(&deployer.DeployParams{
+		StackName: "hello",
+	})
+
+	report, ok := errors.Cause(err).(*failure.Report)
+
+	assert.True(t, ok)
+	assert.Equal(t, "hello", report.StackName)
+	assert.Equal(t, cloudformation.StackStatusUpdateRollbackComplete, report.StackStatus)
+	assert.EqualError(t, err, "changeSet execution error: failed creating/updating stack, status: UPDATE_ROLLBACK_COMPLETE, root cause: Bucket (AWS::S3::Bucket) UPDATE_FAILED: helloza already exists")
+}
+
+func TestDeployPrunesChangeSets(t *testing.T) {
 	tests := map[string]struct {
-		packageParams  *packager.PackageParams
-		exportResp     *packager.Template
-		exportErr      error
-		writeOutputErr error
-		marshallResp   []byte
-		marshallErr    error
+		pruneChangeSets      time.Duration
+		pruneChangeSetsErr   error
+		expectedPrunedStacks []string
 	}{
-		"exits with error with Export has error": {
-			exportErr: errors.New("error"),
-			packageParams: &packager.PackageParams{
-				TemplateFile: "example.yml",
+		"deploy prunes stale change sets when requested": {
+			pruneChangeSets:      7 * 24 * time.Hour,
+			expectedPrunedStacks: []string{"hello"},
+		},
+		"deploy doesn't fail if change sets can't be pruned": {
+			pruneChangeSets:      7 * 24 * time.Hour,
+			pruneChangeSetsErr:   errors.New("throttled"),
+			expectedPrunedStacks: []string{"hello"},
+		},
+		"deploy doesn't prune change sets by default": {
+			expectedPrunedStacks: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			prunedStacks := []string{}
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(mockedDeployer{
+					createChangeSetResp: deployer.ChangeSetRecord{
+						ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("1")},
+					},
+					waitForChangeSetResp: deployer.ChangeSetRecord{
+						ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("1")},
+					},
+					pruneChangeSetsResp: []*cloudformation.ChangeSetSummary{{ChangeSetName: aws.String("cfn-cloudformation-package-deploy-1521961972")}},
+					pruneChangeSetsErr:  test.pruneChangeSetsErr,
+					prunedStacks:        &prunedStacks,
+				}),
+				cfn.Logger(logger))
+
+			_, err := cfn.Deploy(&deployer.DeployParams{
+				StackName:          "hello",
+				NoExecuteChangeset: true,
+				PruneChangeSets:    test.pruneChangeSets,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedPrunedStacks, prunedStacks)
+		})
+	}
+}
+
+type mockedApprover struct {
+	approved bool
+	err      error
+}
+
+func (a mockedApprover) Approve(changeSet *preview.ChangeSet) (bool, error) {
+	return a.approved, a.err
+}
+
+func TestDeployApproval(t *testing.T) {
+	stack := &cloudformation.Stack{
+		StackId:     aws.String("hello"),
+		StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+	}
+
+	tests := map[string]struct {
+		apprvr                    mockedApprover
+		expectedResp              interface{}
+		expectedErr               string
+		expectedDeletedChangeSets []string
+	}{
+		"deploy executes approved change set": {
+			apprvr:                    mockedApprover{approved: true},
+			expectedResp:              stack,
+			expectedDeletedChangeSets: []string{},
+		},
+		"deploy deletes rejected change set": {
+			apprvr:                    mockedApprover{},
+			expectedResp:              "",
+			expectedErr:               "change set cfn-1 has been rejected",
+			expectedDeletedChangeSets: []string{"arn:changeset"},
+		},
+		"deploy returns error if approval fails": {
+			apprvr:                    mockedApprover{err: errors.New("EOF")},
+			expectedResp:              "",
+			expectedErr:               "error while approving change set: EOF",
+			expectedDeletedChangeSets: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			deletedChangeSets := []string{}
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(mockedDeployer{
+					createChangeSetResp: deployer.ChangeSetRecord{
+						ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("arn:changeset")},
+					},
+					waitForChangeSetResp: deployer.ChangeSetRecord{
+						ChangeSet: &cloudformation.DescribeChangeSetOutput{
+							ChangeSetId:   aws.String("arn:changeset"),
+							ChangeSetName: aws.String("cfn-1"),
+						},
+					},
+					waitForExecuteResp: deployer.StackRecord{Stack: stack},
+					deletedChangeSets:  &deletedChangeSets,
+				}),
+				cfn.Approver(test.apprvr),
+				cfn.Logger(logger))
+
+			body, err := cfn.Deploy(&deployer.DeployParams{
+				StackName: "hello",
+			})
+
+			assert.Equal(t, test.expectedResp, body)
+			assert.Equal(t, test.expectedDeletedChangeSets, deletedChangeSets)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeployGuards(t *testing.T) {
+	changeSet := &cloudformation.DescribeChangeSetOutput{
+		ChangeSetId:   aws.String("arn:changeset"),
+		ChangeSetName: aws.String("cfn-1"),
+		Changes: []*cloudformation.Change{
+			{
+				ResourceChange: &cloudformation.ResourceChange{
+					Action:            aws.String(cloudformation.ChangeActionModify),
+					LogicalResourceId: aws.String("Database"),
+					ResourceType:      aws.String("AWS::RDS::DBInstance"),
+					Replacement:       aws.String(cloudformation.ReplacementTrue),
+				},
 			},
 		},
 	}
 
+	tests := map[string]struct {
+		policy             *guard.Policy
+		noExecuteChangeset bool
+		expectedErr        string
+	}{
+		"deploy executes change set allowed by the policy": {
+			policy: &guard.Policy{DenyRemoval: true},
+		},
+		"deploy fails if change set is denied by the policy": {
+			policy:      &guard.Policy{DenyReplacement: []string{"AWS::RDS::DBInstance"}},
+			expectedErr: "changeSet check error: change set cfn-1 is denied by the deploy policy: Database (AWS::RDS::DBInstance) would be replaced, replacement: True",
+		},
+		"deploy fails if change set which isn't executed is denied by the policy": {
+			policy:             &guard.Policy{DenyReplacement: []string{"AWS::RDS::DBInstance"}},
+			noExecuteChangeset: true,
+			expectedErr:        "changeSet check error: change set cfn-1 is denied by the deploy policy: Database (AWS::RDS::DBInstance) would be replaced, replacement: True",
+		},
+		"deploy returns change set which isn't executed and is allowed by the policy": {
+			policy:             &guard.Policy{DenyRemoval: true},
+			noExecuteChangeset: true,
+		},
+	}
+
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			pkgr := &mockerPackager{
-				exportErr: test.exportErr,
+			deletedChangeSets := []string{}
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(mockedDeployer{
+					createChangeSetResp: deployer.ChangeSetRecord{
+						ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("arn:changeset")},
+					},
+					waitForChangeSetResp: deployer.ChangeSetRecord{ChangeSet: changeSet},
+					waitForExecuteResp: deployer.StackRecord{
+						Stack: &cloudformation.Stack{StackStatus: aws.String(cloudformation.StackStatusUpdateComplete)},
+					},
+					deletedChangeSets: &deletedChangeSets,
+				}),
+				cfn.Guard(test.policy),
+				cfn.Logger(logger))
+
+			_, err := cfn.Deploy(&deployer.DeployParams{
+				StackName:          "hello",
+				NoExecuteChangeset: test.noExecuteChangeset,
+			})
+
+			assert.Empty(t, deletedChangeSets)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
 			}
 
+			assert.EqualError(t, err, test.expectedErr)
+
+			_, ok := errors.Cause(err).(*guard.Report)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ruleSet, err := rules.Parse([]byte("Rules:\n  - {Name: no-removal, Target: Change, Assert: \"Action != 'Remove'\"}\n"))
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		dplr               mockedDeployer
+		pckgr              mockerPackager
+		changeSetName      string
+		expectedViolations []*rules.Violation
+		expectedErr        string
+	}{
+		"check evaluates rules against the change set": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						Changes: []*cloudformation.Change{
+							{
+								ResourceChange: &cloudformation.ResourceChange{
+									Action:            aws.String(cloudformation.ChangeActionRemove),
+									LogicalResourceId: aws.String("Queue"),
+									ResourceType:      aws.String("AWS::SQS::Queue"),
+								},
+							},
+						},
+					},
+				},
+			},
+			pckgr:         mockerPackager{openResp: &packager.Template{}},
+			changeSetName: "cfn-1",
+			expectedViolations: []*rules.Violation{
+				{
+					Rule:              "no-removal",
+					Target:            rules.TargetChange,
+					LogicalResourceId: "Queue",
+					ResourceType:      "AWS::SQS::Queue",
+				},
+			},
+		},
+		"check skips change set rules without change set name": {
+			pckgr:              mockerPackager{openResp: &packager.Template{}},
+			expectedViolations: []*rules.Violation{},
+		},
+		"check returns error if template can't be opened": {
+			pckgr:       mockerPackager{opentErr: errors.New("error while opening cfn")},
+			expectedErr: "error while opening cfn",
+		},
+		"check returns error if change set can't be described": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: deployer.ChangeSetRecord{Err: errors.New("ChangeSet does not exist")},
+			},
+			pckgr:         mockerPackager{openResp: &packager.Template{}},
+			changeSetName: "cfn-1",
+			expectedErr:   "error while describing change set: ChangeSet does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
 			logger, _ := logrustest.NewNullLogger()
-			cfn := cfn.NewWithOptions(cfn.Logger(logger), cfn.Packager(pkgr))
-			_, err := cfn.Package(test.packageParams)
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Packager(test.pckgr),
+				cfn.Logger(logger))
 
-			if err != nil {
-				assert.Error(t, test.exportErr, err)
+			violations, err := cfn.Check(&rules.CheckParams{
+				RuleSet:       ruleSet,
+				TemplateFile:  "stack.yml",
+				StackName:     "hello",
+				ChangeSetName: test.changeSetName,
+			})
+
+			assert.Equal(t, test.expectedViolations, violations)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
 			}
 		})
 	}
 }
+
+type mockedNotifier struct {
+	phases *[]notifier.Phase
+}
+
+func (n mockedNotifier) Notify(notification *notifier.Notification) error {
+	*n.phases = append(*n.phases, notification.Phase)
+	return errors.New("webhook is down")
+}
+
+func TestDeployNotifications(t *testing.T) {
+	tests := map[string]struct {
+		dplr               mockedDeployer
+		noExecuteChangeset bool
+		expectedPhases     []notifier.Phase
+	}{
+		"notifies about each phase of successful deployment": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("1"),
+					},
+					ClientRequestToken: aws.String("token"),
+				},
+				waitForChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("1"),
+					},
+				},
+				waitForExecuteResp: deployer.StackRecord{
+					Stack: &cloudformation.Stack{
+						StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+					},
+				},
+			},
+			expectedPhases: []notifier.Phase{notifier.PhaseStarted, notifier.PhaseChangeSet, notifier.PhaseSucceeded},
+		},
+		"doesn't notify about success when change set isn't executed": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("1"),
+					},
+				},
+				waitForChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("1"),
+					},
+				},
+			},
+			noExecuteChangeset: true,
+			expectedPhases:     []notifier.Phase{notifier.PhaseStarted, notifier.PhaseChangeSet},
+		},
+		"notifies about deployment without changes": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("1"),
+					},
+				},
+				waitForChangeSetResp: deployer.ChangeSetRecord{
+					Err: errors.New("The submitted information didn't contain changes."),
+				},
+				describeStackUnsafeResp: cloudformation.Stack{
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+				},
+			},
+			expectedPhases: []notifier.Phase{notifier.PhaseStarted, notifier.PhaseNoChanges},
+		},
+		"notifies about failed deployment": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					Err: errors.New("error"),
+				},
+			},
+			expectedPhases: []notifier.Phase{notifier.PhaseStarted, notifier.PhaseFailed},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			phases := []notifier.Phase{}
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Notifier(mockedNotifier{phases: &phases}),
+				cfn.Logger(logger))
+
+			cfn.Deploy(&deployer.DeployParams{
+				StackName:          "hello",
+				NoExecuteChangeset: test.noExecuteChangeset,
+			})
+
+			assert.Equal(t, test.expectedPhases, phases)
+		})
+	}
+}
+
+type mockedReporter struct {
+	op **reporter.Operation
+}
+
+func (r mockedReporter) Report(op *reporter.Operation) error {
+	*r.op = op
+	return nil
+}
+
+func TestDeployReporters(t *testing.T) {
+	var op *reporter.Operation
+
+	events := streamer.StackEventList{
+		{
+			EventId:        aws.String("1"),
+			ResourceStatus: aws.String(cloudformation.ResourceStatusUpdateComplete),
+		},
+	}
+
+	logger, _ := logrustest.NewNullLogger()
+	cfn := cfn.NewWithOptions(
+		cfn.Deployer(mockedDeployer{
+			createChangeSetResp: deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("1"),
+				},
+				ClientRequestToken: aws.String("token"),
+			},
+			waitForChangeSetResp: deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("1"),
+				},
+			},
+			waitForExecuteResp: deployer.StackRecord{
+				Stack: &cloudformation.Stack{
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+				},
+			},
+		}),
+		cfn.Streamer(mockedStreamer{
+			describeStackEventsResp: streamer.StackEventsRecord{
+				Records: events,
+			},
+		}),
+		cfn.Reporter(mockedReporter{op: &op}),
+		cfn.Logger(logger))
+
+	_, err := cfn.Deploy(&deployer.DeployParams{
+		StackName: "hello",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", op.StackName)
+	assert.Equal(t, "token", op.ClientRequestToken)
+	assert.Equal(t, aws.String("1"), op.ChangeSet.ChangeSetId)
+	assert.Equal(t, cloudformation.StackStatusUpdateComplete, *op.Stack.StackStatus)
+	assert.Equal(t, events, op.Events)
+	assert.False(t, op.EndTime.Before(op.StartTime))
+}
+
+func TestOperationEvents(t *testing.T) {
+	events := streamer.StackEventList{
+		{EventId: aws.String("1")},
+	}
+
+	tests := map[string]struct {
+		clientRequestToken string
+		describeStackResp  deployer.StackRecord
+		expectedEvents     streamer.StackEventList
+		expectedErr        string
+	}{
+		"returns events of the given operation": {
+			clientRequestToken: "token",
+			expectedEvents:     events,
+		},
+		"returns events of the latest operation": {
+			describeStackResp: deployer.StackRecord{
+				Stack: &cloudformation.Stack{
+					CreationTime: aws.Time(time.Now()),
+				},
+			},
+			expectedEvents: events,
+		},
+		"returns error if stack can't be described": {
+			describeStackResp: deployer.StackRecord{
+				Err: errors.New("stack hello does not exist"),
+			},
+			expectedErr: "error while describing stack: stack hello does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(mockedDeployer{
+					describeStackResp: test.describeStackResp,
+				}),
+				cfn.Streamer(mockedStreamer{
+					describeStackEventsResp: streamer.StackEventsRecord{
+						Records: events,
+					},
+				}),
+				cfn.Logger(logger))
+
+			actual, err := cfn.OperationEvents("hello", test.clientRequestToken)
+
+			assert.Equal(t, test.expectedEvents, actual)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTemplateDiff(t *testing.T) {
+	tests := map[string]struct {
+		dplr            mockedDeployer
+		templateFile    string
+		expectedChanges []*templatediff.Change
+		expectedErr     string
+	}{
+		"template diff compares the template with the deployed template": {
+			dplr:         mockedDeployer{getTemplateResp: "Resources:\n  Queue:\n    Type: AWS::SQS::Queue\n"},
+			templateFile: "stack.yml",
+			expectedChanges: []*templatediff.Change{
+				{
+					Section:   "Resources",
+					LogicalId: "Queue",
+					Action:    "Remove",
+					Hunks: []*templatediff.Hunk{
+						{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []string{"-Type: AWS::SQS::Queue"}},
+					},
+				},
+			},
+		},
+		"template diff returns error if deployed template can't be fetched": {
+			dplr:         mockedDeployer{getTemplateErr: errors.New("AWS error while running GetTemplate")},
+			templateFile: "stack.yml",
+			expectedErr:  "AWS error while running GetTemplate",
+		},
+		"template diff returns error if template can't be read": {
+			dplr:         mockedDeployer{getTemplateResp: "Resources: {}\n"},
+			templateFile: "missing.yml",
+			expectedErr:  "error while reading template: open missing.yml: file does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afero.WriteFile(fs, "stack.yml", []byte("Resources: {}\n"), 0644)
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Packager(packager.New(logger, fs)),
+				cfn.Fs(fs),
+				cfn.Logger(logger))
+
+			changes, err := cfn.TemplateDiff("hello", test.templateFile)
+
+			assert.Equal(t, test.expectedChanges, changes)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	lastUpdatedTime := time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+	tests := map[string]struct {
+		dplr          mockedDeployer
+		parameters    []*cloudformation.Parameter
+		expectedPlan  *plan.Plan
+		expectedErr   string
+		hasChangeSet  bool
+		emptyTemplate bool
+	}{
+		"plan describes the change set and the stack without NoEcho values": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet:          &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("arn:changeset")},
+					ChangeSetType:      aws.String(cloudformation.ChangeSetTypeUpdate),
+					ClientRequestToken: aws.String("token"),
+					Stack:              &cloudformation.Stack{LastUpdatedTime: &lastUpdatedTime},
+				},
+				waitForChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId: aws.String("arn:changeset"),
+						StackId:     aws.String("arn:hello"),
+						Parameters: []*cloudformation.Parameter{
+							{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+							{ParameterKey: aws.String("Password"), ParameterValue: aws.String("****")},
+						},
+					},
+				},
+				describeStackResp: deployer.StackRecord{
+					Err: errors.New("stack is described with the change set"),
+				},
+			},
+			parameters: []*cloudformation.Parameter{
+				{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+				{ParameterKey: aws.String("Password"), ParameterValue: aws.String("secret")},
+			},
+			expectedPlan: &plan.Plan{
+				Version:            plan.Version,
+				StackName:          "hello",
+				StackId:            "arn:hello",
+				ChangeSetId:        "arn:changeset",
+				ChangeSetType:      cloudformation.ChangeSetTypeUpdate,
+				ClientRequestToken: "token",
+				TemplateHash:       "49cbd1e9008e8b67dec76613bb4d6468c822c99701820526928d43b382f35fba",
+				Parameters: []*cloudformation.Parameter{
+					{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+					{ParameterKey: aws.String("Password"), UsePreviousValue: aws.Bool(true)},
+				},
+				StackLastUpdatedTime: &lastUpdatedTime,
+			},
+			hasChangeSet: true,
+		},
+		"plan is nil when change set contains no changes": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("arn:changeset")},
+				},
+				waitForChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("arn:changeset")},
+					Err:       errors.New("The submitted information didn't contain changes. Submit different information to create a change set."),
+				},
+			},
+			hasChangeSet: true,
+		},
+		"plan returns error if change set can't be created": {
+			dplr: mockedDeployer{
+				createChangeSetResp: deployer.ChangeSetRecord{
+					Err: errors.New("error"),
+				},
+			},
+			expectedErr: "changeSet creation error: error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afero.WriteFile(fs, "stack.yml", []byte("Resources: {}\n"), 0644)
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Fs(fs),
+				cfn.Logger(logger))
+
+			p, changeSet, err := cfn.Plan(&deployer.DeployParams{
+				StackName:    "hello",
+				TemplateFile: "stack.yml",
+				Parameters:   test.parameters,
+			})
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.hasChangeSet, changeSet != nil)
+
+			if test.expectedPlan == nil {
+				assert.Nil(t, p)
+				return
+			}
+
+			assert.False(t, p.CreatedAt.IsZero())
+			p.CreatedAt = time.Time{}
+			assert.Equal(t, test.expectedPlan, p)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	lastUpdatedTime := time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+	availableChangeSet := deployer.ChangeSetRecord{
+		ChangeSet: &cloudformation.DescribeChangeSetOutput{
+			ChangeSetId:     aws.String("arn:changeset"),
+			ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+		},
+	}
+
+	stack := &cloudformation.Stack{
+		StackId:         aws.String("arn:hello"),
+		StackStatus:     aws.String(cloudformation.StackStatusUpdateComplete),
+		LastUpdatedTime: &lastUpdatedTime,
+	}
+
+	tests := map[string]struct {
+		dplr          mockedDeployer
+		templateFile  string
+		expectedStack *cloudformation.Stack
+		expectedErr   string
+	}{
+		"apply executes change set of unchanged stack": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: availableChangeSet,
+				describeStackResp:     deployer.StackRecord{Stack: stack},
+				waitForExecuteResp:    deployer.StackRecord{Stack: stack},
+			},
+			templateFile:  "stack.yml",
+			expectedStack: stack,
+		},
+		"apply returns error if template has changed": {
+			templateFile: "changed.yml",
+			expectedErr:  "template changed.yml has changed since the plan was created",
+		},
+		"apply returns error if change set doesn't exist": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: deployer.ChangeSetRecord{
+					Err: errors.New("ChangeSet does not exist"),
+				},
+			},
+			expectedErr: "error while describing change set of the plan: ChangeSet does not exist",
+		},
+		"apply returns error if change set has been executed": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusExecuteComplete),
+					},
+				},
+			},
+			expectedErr: "change set of the plan can't be executed, its execution status is EXECUTE_COMPLETE",
+		},
+		"apply returns error if stack has been updated": {
+			dplr: mockedDeployer{
+				describeChangeSetResp: availableChangeSet,
+				describeStackResp: deployer.StackRecord{
+					Stack: &cloudformation.Stack{
+						StackId:         aws.String("arn:hello"),
+						LastUpdatedTime: aws.Time(lastUpdatedTime.Add(time.Minute)),
+					},
+				},
+			},
+			expectedErr: "stack hello has been updated since the plan was created",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afero.WriteFile(fs, "stack.yml", []byte("Resources: {}\n"), 0644)
+			afero.WriteFile(fs, "changed.yml", []byte("Resources: {Queue: {Type: AWS::SQS::Queue}}\n"), 0644)
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Fs(fs),
+				cfn.Logger(logger))
+
+			actual, err := cfn.Apply(&plan.Plan{
+				Version:              plan.Version,
+				StackName:            "hello",
+				StackId:              "arn:hello",
+				ChangeSetId:          "arn:changeset",
+				ChangeSetType:        cloudformation.ChangeSetTypeUpdate,
+				ClientRequestToken:   "token",
+				TemplateHash:         "49cbd1e9008e8b67dec76613bb4d6468c822c99701820526928d43b382f35fba",
+				StackLastUpdatedTime: &lastUpdatedTime,
+			}, test.templateFile)
+
+			assert.Equal(t, test.expectedStack, actual)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExecuteChangeSet(t *testing.T) {
+	stack := &cloudformation.Stack{
+		StackId:     aws.String("arn:hello"),
+		StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+	}
+
+	tests := map[string]struct {
+		dplr          mockedDeployer
+		expectedStack *cloudformation.Stack
+		expectedErr   string
+	}{
+		"execute change set waits for the stack": {
+			dplr: mockedDeployer{
+				existingChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ChangeSetId:     aws.String("arn:changeset"),
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+					},
+					ChangeSetType:      aws.String(cloudformation.ChangeSetTypeCreate),
+					ClientRequestToken: aws.String("token"),
+				},
+				waitForExecuteResp: deployer.StackRecord{Stack: stack},
+			},
+			expectedStack: stack,
+		},
+		"execute change set returns error if change set doesn't exist": {
+			dplr: mockedDeployer{
+				existingChangeSetResp: deployer.ChangeSetRecord{
+					Err: errors.New("ChangeSet does not exist"),
+				},
+			},
+			expectedErr: "error while describing change set: ChangeSet does not exist",
+		},
+		"execute change set returns error if change set isn't available": {
+			dplr: mockedDeployer{
+				existingChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusUnavailable),
+					},
+				},
+			},
+			expectedErr: "change set one can't be executed, its execution status is UNAVAILABLE",
+		},
+		"execute change set returns error if execution fails": {
+			dplr: mockedDeployer{
+				existingChangeSetResp: deployer.ChangeSetRecord{
+					ChangeSet: &cloudformation.DescribeChangeSetOutput{
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+					},
+				},
+				executeChangesetErr: errors.New("error"),
+			},
+			expectedErr: "changeSet execution error: error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(test.dplr),
+				cfn.Logger(logger))
+
+			actual, err := cfn.ExecuteChangeSet("hello", "one")
+
+			assert.Equal(t, test.expectedStack, actual)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestManageChangeSets(t *testing.T) {
+	logger, _ := logrustest.NewNullLogger()
+	summaries := []*cloudformation.ChangeSetSummary{{ChangeSetName: aws.String("one")}}
+
+	c := cfn.NewWithOptions(
+		cfn.Deployer(mockedDeployer{
+			describeChangeSetResp: deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{ChangeSetName: aws.String("one")},
+			},
+			listChangeSetsResp: summaries,
+		}),
+		cfn.Logger(logger))
+
+	changeSet, err := c.DescribeChangeSet("hello", "one")
+	assert.NoError(t, err)
+	assert.Equal(t, aws.String("one"), changeSet.ChangeSetName)
+
+	actual, err := c.ListChangeSets("hello")
+	assert.NoError(t, err)
+	assert.Equal(t, summaries, actual)
+
+	assert.NoError(t, c.DeleteChangeSet("hello", "one"))
+
+	c = cfn.NewWithOptions(
+		cfn.Deployer(mockedDeployer{
+			describeChangeSetResp: deployer.ChangeSetRecord{Err: errors.New("error")},
+			deleteChangeSetErr:    errors.New("error"),
+			listChangeSetsErr:     errors.New("error"),
+		}),
+		cfn.Logger(logger))
+
+	_, err = c.DescribeChangeSet("hello", "one")
+	assert.EqualError(t, err, "error while describing change set: error")
+
+	_, err = c.ListChangeSets("hello")
+	assert.EqualError(t, err, "error while listing change sets: error")
+
+	assert.EqualError(t, c.DeleteChangeSet("hello", "one"), "error while deleting change set: error")
+}
+
+func TestTail(t *testing.T) {
+	tests := map[string]struct {
+		waitForStableResp deployer.StackRecord
+		expectedStack     *cloudformation.Stack
+		expectedErr       string
+		isReport          bool
+	}{
+		"tail returns the stack when operation has succeeded": {
+			waitForStableResp: deployer.StackRecord{
+				Stack: &cloudformation.Stack{
+					StackName:   aws.String("hello"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+				},
+			},
+			expectedStack: &cloudformation.Stack{
+				StackName:   aws.String("hello"),
+				StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+			},
+		},
+		"tail returns failure report when operation has rolled back": {
+			waitForStableResp: deployer.StackRecord{
+				Stack: &cloudformation.Stack{
+					StackName:   aws.String("hello"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackComplete),
+				},
+			},
+			expectedStack: &cloudformation.Stack{
+				StackName:   aws.String("hello"),
+				StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackComplete),
+			},
+			expectedErr: "failed creating/updating stack, status: UPDATE_ROLLBACK_COMPLETE",
+			isReport:    true,
+		},
+		"tail returns error when stack does not exist": {
+			expectedErr: "stack hello does not exist",
+		},
+		"tail returns error when waiting has failed": {
+			waitForStableResp: deployer.StackRecord{
+				Err: errors.New("timeout"),
+			},
+			expectedErr: "error while waiting for stack to become stable: timeout",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(mockedDeployer{
+					waitForStableResp: test.waitForStableResp,
+				}),
+				cfn.Streamer(mockedStreamer{}),
+				cfn.Logger(logger))
+
+			stack, err := cfn.Tail("hello", time.Minute)
+
+			assert.Equal(t, test.expectedStack, stack)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, test.expectedErr)
+
+			_, ok := errors.Cause(err).(*failure.Report)
+			assert.Equal(t, test.isReport, ok)
+		})
+	}
+}
+
+func TestPackage(t *testing.T) {
+	tests := map[string]struct {
+		packageParams  *packager.PackageParams
+		exportResp     *packager.Template
+		exportErr      error
+		writeOutputErr error
+		marshallResp   []byte
+		marshallErr    error
+	}{
+		"exits with error with Export has error": {
+			exportErr: errors.New("error"),
+			packageParams: &packager.PackageParams{
+				TemplateFile: "example.yml",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pkgr := &mockerPackager{
+				exportErr: test.exportErr,
+			}
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(cfn.Logger(logger), cfn.Packager(pkgr))
+			_, err := cfn.Package(test.packageParams)
+
+			if err != nil {
+				assert.Error(t, test.exportErr, err)
+			}
+		})
+	}
+}
+
+type namedSink string
+
+func (s namedSink) Write(event interface{}) {}
+
+type sinkRecordingStreamer struct {
+	mockedStreamer
+	sink *streamer.EventSink
+}
+
+func (s sinkRecordingStreamer) StartStreaming(stackName *string, filter *streamer.EventFilter, sink streamer.EventSink, done <-chan bool) error {
+	*s.sink = sink
+	return nil
+}
+
+type streamingDeployer struct {
+	mockedDeployer
+	sink streamer.EventSink
+}
+
+func (s streamingDeployer) WaitForStable(stackName *string, timeout time.Duration, stmr streamer.Streameriface) *deployer.StackRecord {
+	if stmr != nil {
+		stmr.StartStreaming(stackName, nil, s.sink, nil)
+	}
+
+	return &s.waitForStableResp
+}
+
+func TestEventSink(t *testing.T) {
+	deployerSink := namedSink("deployer")
+	eventSink := namedSink("events")
+
+	tests := map[string]struct {
+		stream       bool
+		eventSink    streamer.EventSink
+		expectedSink streamer.EventSink
+	}{
+		"events are streamed into the deployer sink by default": {
+			stream:       true,
+			expectedSink: deployerSink,
+		},
+		"events are streamed into the given sink": {
+			stream:       true,
+			eventSink:    eventSink,
+			expectedSink: eventSink,
+		},
+		"events aren't streamed unless streaming is required": {
+			stream:    false,
+			eventSink: eventSink,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sink streamer.EventSink
+
+			logger, _ := logrustest.NewNullLogger()
+			cfn := cfn.NewWithOptions(
+				cfn.Deployer(streamingDeployer{
+					mockedDeployer: mockedDeployer{
+						waitForStableResp: deployer.StackRecord{
+							Stack: &cloudformation.Stack{
+								StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+							},
+						},
+					},
+					sink: deployerSink,
+				}),
+				cfn.Streamer(sinkRecordingStreamer{sink: &sink}),
+				cfn.Stream(test.stream),
+				cfn.EventSink(test.eventSink),
+				cfn.Logger(logger))
+
+			_, err := cfn.Tail("hello", time.Minute)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedSink, sink)
+		})
+	}
+}
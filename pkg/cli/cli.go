@@ -7,6 +7,8 @@ import (
 
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 )
@@ -14,6 +16,12 @@ import (
 type CFNParametersValue []*cloudformation.Parameter
 type CFNTagsValue []*cloudformation.Tag
 
+// ListValue comma separated list of values, the flag can be repeated
+type ListValue []string
+
+// SinceValue point in time given either as RFC3339 timestamp or as duration ago, e.g. 2h
+type SinceValue time.Time
+
 // stringToKeyVal converts string key1=val1 key2="val2 val3" into map
 func stringToKeyVal(value string) (map[string]string, error) {
 	keyVal := make(map[string]string)
@@ -90,3 +98,54 @@ func CFNTags(s kingpin.Settings) (target *CFNTagsValue) {
 	s.SetValue(target)
 	return
 }
+
+func (h *SinceValue) Set(value string) error {
+	if d, err := time.ParseDuration(value); err == nil {
+		*h = SinceValue(time.Now().Add(-d))
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("expected duration e.g. 2h or RFC3339 timestamp got '%s'", value)
+	}
+
+	*h = SinceValue(t)
+
+	return nil
+}
+
+func (h *SinceValue) String() string {
+	return ""
+}
+
+func Since(s kingpin.Settings) (target *SinceValue) {
+	target = &SinceValue{}
+	s.SetValue(target)
+	return
+}
+
+func (h *ListValue) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*h = append(*h, v)
+		}
+	}
+
+	return nil
+}
+
+func (h *ListValue) String() string {
+	return strings.Join(*h, ",")
+}
+
+// IsCumulative allows the flag to be repeated
+func (h *ListValue) IsCumulative() bool {
+	return true
+}
+
+func List(s kingpin.Settings) (target *ListValue) {
+	target = &ListValue{}
+	s.SetValue(target)
+	return
+}
@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"testing"
+	"time"
 
 	"fmt"
 
@@ -71,3 +72,52 @@ func TestCFNTagsValue(t *testing.T) {
 		})
 	}
 }
+
+func TestSinceValue(t *testing.T) {
+	tests := map[string]struct {
+		passedSince   string
+		expectedSince time.Time
+		expectedErr   bool
+	}{
+		"duration is subtracted from now": {
+			passedSince:   "2h",
+			expectedSince: time.Now().Add(-2 * time.Hour),
+		},
+		"timestamp is parsed as RFC3339": {
+			passedSince:   "2018-03-25T07:12:52Z",
+			expectedSince: time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC),
+		},
+		"anything else is an error": {
+			passedSince: "yesterday",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := kingpin.New("test", "").Terminate(nil)
+			s := cli.Since(a.Flag("since", ""))
+			_, err := a.Parse([]string{fmt.Sprintf("--since=%s", test.passedSince)})
+
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.WithinDuration(t, test.expectedSince, time.Time(*s), time.Minute)
+		})
+	}
+}
+
+func TestListValue(t *testing.T) {
+	a := kingpin.New("test", "").Terminate(nil)
+	l := cli.List(a.Flag("deny-replacement", ""))
+	_, err := a.Parse([]string{
+		"--deny-replacement=AWS::RDS::DBInstance, AWS::DynamoDB::Table",
+		"--deny-replacement=AWS::S3::Bucket",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, cli.ListValue{"AWS::RDS::DBInstance", "AWS::DynamoDB::Table", "AWS::S3::Bucket"}, *l)
+}
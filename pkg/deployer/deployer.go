@@ -1,8 +1,11 @@
 package deployer
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -14,10 +17,6 @@ import (
 	"github.com/b-b3rn4rd/gocfn/pkg/writer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	//"sync"
-	//"os"
-	"os"
-	"sync"
 
 	"github.com/b-b3rn4rd/gocfn/pkg/uploader"
 )
@@ -35,40 +34,102 @@ type DeployParams struct {
 	FailOnEmptyChangeset bool
 	Tags                 []*cloudformation.Tag
 	ForceDeploy          bool
+	WaitForStable        bool
+	WaitForStableTimeout time.Duration
+	PruneChangeSets      time.Duration
+	TemplateDiff         bool
 }
 
 type Deployeriface interface {
 	WaitForChangeSet(*string, *string) *ChangeSetRecord
 	WaitForExecute(*string, *ChangeSetRecord, streamer.Streameriface) *StackRecord
-	ExecuteChangeset(*string, *string) error
+	ExecuteChangeset(*string, *string, *string) error
 	CreateChangeSet(deployParams *DeployParams) *ChangeSetRecord
 	DescribeStackUnsafe(stackName *string) *cloudformation.Stack
+	DescribeStack(stackName *string) *StackRecord
+	DescribeChangeSet(stackName *string, changeSetID *string) *ChangeSetRecord
+	ExistingChangeSet(stackName *string, changeSetName *string) *ChangeSetRecord
+	DeleteChangeSet(stackName *string, changeSetName *string) error
+	ListChangeSets(stackName *string) ([]*cloudformation.ChangeSetSummary, error)
+	PruneChangeSets(stackName *string, olderThan time.Duration) ([]*cloudformation.ChangeSetSummary, error)
+	GetTemplate(stackName *string) (string, error)
+	WaitForStable(*string, time.Duration, streamer.Streameriface) *StackRecord
+}
+
+// StableTimeoutError is returned when stack did not reach a stable state in time
+type StableTimeoutError struct {
+	StackName   string
+	StackStatus string
+	Timeout     time.Duration
+}
+
+func (e *StableTimeoutError) Error() string {
+	return fmt.Sprintf("stack %s is still in %s after waiting for %s", e.StackName, e.StackStatus, e.Timeout)
 }
 
 type StackRecord struct {
-	Stack *cloudformation.Stack
-	Err   error
+	Stack     *cloudformation.Stack
+	StreamErr error
+	Err       error
 }
 
 type ChangeSetRecord struct {
-	ChangeSet     *cloudformation.DescribeChangeSetOutput
-	StackEvents   streamer.StackEvents
-	ChangeSetType *string
-	Err           error
+	ChangeSet          *cloudformation.DescribeChangeSetOutput
+	ClientRequestToken *string
+	ChangeSetType      *string
+	Stack              *cloudformation.Stack
+	Err                error
+}
+
+// EventFilter narrows down stack events to the ones caused by executing the change set
+func (r *ChangeSetRecord) EventFilter() *streamer.EventFilter {
+	filter := &streamer.EventFilter{
+		ClientRequestToken: r.ClientRequestToken,
+	}
+
+	if r.ChangeSet != nil {
+		filter.Since = aws.TimeValue(r.ChangeSet.CreationTime)
+	}
+
+	return filter
 }
 
 type Deployer struct {
 	svc             cloudformationiface.CloudFormationAPI
 	logger          *logrus.Logger
 	changesetPrefix string
+	pollInterval    time.Duration
+	eventSink       streamer.EventSink
+}
+
+// PollInterval sets how often stack status is checked while waiting for it to become stable
+func PollInterval(pollInterval time.Duration) func(dplr *Deployer) {
+	return func(dplr *Deployer) {
+		dplr.pollInterval = pollInterval
+	}
 }
 
-func New(svc cloudformationiface.CloudFormationAPI, logger *logrus.Logger) *Deployer {
-	return &Deployer{
+// EventSink sets the sink receiving streamed stack events
+func EventSink(eventSink streamer.EventSink) func(dplr *Deployer) {
+	return func(dplr *Deployer) {
+		dplr.eventSink = eventSink
+	}
+}
+
+func New(svc cloudformationiface.CloudFormationAPI, logger *logrus.Logger, options ...func(dplr *Deployer)) *Deployer {
+	dplr := &Deployer{
 		svc:             svc,
 		logger:          logger,
 		changesetPrefix: "cfn-cloudformation-package-deploy",
+		pollInterval:    time.Second * 10,
+		eventSink:       writer.New(os.Stderr, writer.JSONFormatter),
+	}
+
+	for _, option := range options {
+		option(dplr)
 	}
+
+	return dplr
 }
 
 func (s *Deployer) hasStack(stackName *string) (bool, *cloudformation.Stack, error) {
@@ -113,6 +174,12 @@ func (s *Deployer) createChangeSet(deployParams *DeployParams) (res *ChangeSetRe
 	changesetName := fmt.Sprintf("%s-%s", s.changesetPrefix, strconv.FormatInt(time.Now().Unix(), 10))
 	description := fmt.Sprintf("Created by cfn at %s", time.Now().UTC().String())
 
+	clientRequestToken, err := s.clientRequestToken()
+	if err != nil {
+		res.Err = errors.Wrap(err, "error while generating client request token")
+		return
+	}
+
 	changeSetInput := &cloudformation.CreateChangeSetInput{
 		ChangeSetName: aws.String(changesetName),
 		StackName:     aws.String(deployParams.StackName),
@@ -120,6 +187,7 @@ func (s *Deployer) createChangeSet(deployParams *DeployParams) (res *ChangeSetRe
 		Tags:          deployParams.Tags,
 		Capabilities:  aws.StringSlice(deployParams.Capabilities),
 		Description:   aws.String(description),
+		ClientToken:   clientRequestToken,
 	}
 
 	hasStack, stack, err := s.hasStack(aws.String(deployParams.StackName))
@@ -146,6 +214,7 @@ func (s *Deployer) createChangeSet(deployParams *DeployParams) (res *ChangeSetRe
 	if hasStack {
 		changeSetInput.ChangeSetType = aws.String(cloudformation.ChangeSetTypeUpdate)
 		deployParams.Parameters = s.mergeParameters(deployParams.Parameters, stack)
+		res.Stack = stack
 	}
 
 	changeSetInput.Parameters = deployParams.Parameters
@@ -184,6 +253,7 @@ func (s *Deployer) createChangeSet(deployParams *DeployParams) (res *ChangeSetRe
 
 	res.ChangeSetType = changeSetInput.ChangeSetType
 	res.ChangeSet.ChangeSetId = resp.Id
+	res.ClientRequestToken = clientRequestToken
 
 	return
 }
@@ -211,6 +281,190 @@ func (s *Deployer) mergeParameters(parameters []*cloudformation.Parameter, stack
 	return parameters
 }
 
+// DescribeChangeSet describes the change set without waiting for it to be created
+func (s *Deployer) DescribeChangeSet(stackName *string, changeSetID *string) (res *ChangeSetRecord) {
+	res = &ChangeSetRecord{}
+
+	s.logger.WithField("stackName", *stackName).Debug("Running DescribeChangeSet")
+
+	resp, err := s.describeChangeSet(&cloudformation.DescribeChangeSetInput{
+		StackName:     stackName,
+		ChangeSetName: changeSetID,
+	})
+
+	if err != nil {
+		res.Err = errors.Wrap(err, "AWS error while running DescribeChangeSet")
+		return
+	}
+
+	res.ChangeSet = resp
+
+	return
+}
+
+// describeChangeSet describes the change set following NextToken, changes of all pages are returned in the first one
+func (s *Deployer) describeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	resp, err := s.svc.DescribeChangeSet(input)
+	if err != nil {
+		return resp, err
+	}
+
+	for page := resp; page.NextToken != nil; {
+		page, err = s.svc.DescribeChangeSet(&cloudformation.DescribeChangeSetInput{
+			StackName:     input.StackName,
+			ChangeSetName: input.ChangeSetName,
+			NextToken:     page.NextToken,
+		})
+		if err != nil {
+			return resp, err
+		}
+
+		resp.Changes = append(resp.Changes, page.Changes...)
+	}
+
+	resp.NextToken = nil
+
+	return resp, nil
+}
+
+// ExistingChangeSet describes the change set created earlier and prepares it for ExecuteChangeset and
+// WaitForExecute, change set type is determined from the stack status and a new client request token is generated
+func (s *Deployer) ExistingChangeSet(stackName *string, changeSetName *string) (res *ChangeSetRecord) {
+	res = s.DescribeChangeSet(stackName, changeSetName)
+	if res.Err != nil {
+		return
+	}
+
+	hasStack, _, err := s.hasStack(stackName)
+	if err != nil {
+		res.Err = err
+		return
+	}
+
+	res.ChangeSetType = aws.String(cloudformation.ChangeSetTypeCreate)
+	if hasStack {
+		res.ChangeSetType = aws.String(cloudformation.ChangeSetTypeUpdate)
+	}
+
+	res.ClientRequestToken, err = s.clientRequestToken()
+	if err != nil {
+		res.Err = errors.Wrap(err, "error while generating client request token")
+	}
+
+	return
+}
+
+// DeleteChangeSet deletes the change set, stack in REVIEW_IN_PROGRESS is left behind
+func (s *Deployer) DeleteChangeSet(stackName *string, changeSetName *string) error {
+	s.logger.WithField("stackName", *stackName).Debug("Running DeleteChangeSet")
+
+	_, err := s.svc.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+		StackName:     stackName,
+		ChangeSetName: changeSetName,
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "AWS error while running DeleteChangeSet")
+	}
+
+	return nil
+}
+
+// GetTemplate returns the template of the deployed stack as it was submitted, before transforms are processed
+func (s *Deployer) GetTemplate(stackName *string) (string, error) {
+	s.logger.WithField("stackName", *stackName).Debug("Running GetTemplate")
+
+	resp, err := s.svc.GetTemplate(&cloudformation.GetTemplateInput{
+		StackName:     stackName,
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	})
+
+	if err != nil {
+		return "", errors.Wrap(err, "AWS error while running GetTemplate")
+	}
+
+	return aws.StringValue(resp.TemplateBody), nil
+}
+
+// ListChangeSets lists all change sets of the stack
+func (s *Deployer) ListChangeSets(stackName *string) ([]*cloudformation.ChangeSetSummary, error) {
+	summaries := []*cloudformation.ChangeSetSummary{}
+
+	s.logger.WithField("stackName", *stackName).Debug("Running ListChangeSets")
+
+	input := &cloudformation.ListChangeSetsInput{
+		StackName: stackName,
+	}
+
+	for {
+		resp, err := s.svc.ListChangeSets(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "AWS error while running ListChangeSets")
+		}
+
+		summaries = append(summaries, resp.Summaries...)
+
+		if resp.NextToken == nil {
+			return summaries, nil
+		}
+
+		input.NextToken = resp.NextToken
+	}
+}
+
+// PruneChangeSets deletes change sets created by the deployer which are older than olderThan,
+// deleted change sets are returned. Nothing is pruned when the stack does not exist
+func (s *Deployer) PruneChangeSets(stackName *string, olderThan time.Duration) ([]*cloudformation.ChangeSetSummary, error) {
+	pruned := []*cloudformation.ChangeSetSummary{}
+
+	summaries, err := s.ListChangeSets(stackName)
+	if err != nil {
+		if strings.Contains(err.Error(), fmt.Sprintf("Stack with id %s does not exist", *stackName)) {
+			return pruned, nil
+		}
+
+		return pruned, err
+	}
+
+	deadline := time.Now().Add(-olderThan)
+
+	for _, summary := range summaries {
+		if !strings.HasPrefix(aws.StringValue(summary.ChangeSetName), s.changesetPrefix+"-") || !aws.TimeValue(summary.CreationTime).Before(deadline) {
+			continue
+		}
+
+		s.logger.WithField("stackName", *stackName).WithField("changeSetName", aws.StringValue(summary.ChangeSetName)).Debug("Pruning stale change set")
+
+		if err := s.DeleteChangeSet(stackName, summary.ChangeSetId); err != nil {
+			return pruned, err
+		}
+
+		pruned = append(pruned, summary)
+	}
+
+	return pruned, nil
+}
+
+// DescribeStack describes the stack, an error is returned when stack does not exist
+func (s *Deployer) DescribeStack(stackName *string) (res *StackRecord) {
+	res = &StackRecord{}
+
+	hasStack, stack, err := s.hasStack(stackName)
+	if err != nil {
+		res.Err = err
+		return
+	}
+
+	if !hasStack {
+		res.Err = fmt.Errorf("stack %s does not exist", *stackName)
+		return
+	}
+
+	res.Stack = stack
+
+	return
+}
+
 // Use following function ONLY when you are 100% confident that the stack exists
 func (s *Deployer) DescribeStackUnsafe(stackName *string) *cloudformation.Stack {
 	resp, _ := s.svc.DescribeStacks(&cloudformation.DescribeStacksInput{
@@ -234,24 +488,29 @@ func (s *Deployer) WaitForChangeSet(stackName *string, changeSetID *string) (res
 
 	err := s.svc.WaitUntilChangeSetCreateComplete(describeChangeSetInput)
 
-	resp, _ := s.svc.DescribeChangeSet(describeChangeSetInput)
+	resp, describeErr := s.describeChangeSet(describeChangeSetInput)
 	res.ChangeSet = resp
 
 	if err != nil {
 		if strings.Contains(*resp.StatusReason, "The submitted information didn't contain changes.") {
-			s.logger.WithField("stackName", *stackName).Debug("ChangeSet does not contain changes")
+			s.logger.WithField("stackName", *stackName).Debug("ChangeSet does not contain changes, deleting it")
 			res.Err = errors.New(*resp.StatusReason)
+
+			if err := s.DeleteChangeSet(stackName, changeSetID); err != nil {
+				s.logger.WithField("stackName", *stackName).WithError(err).Warn("error while deleting empty change set")
+			}
 		} else {
 			res.Err = errors.Wrap(err, "AWS error while running WaitUntilChangeSetCreateComplete")
 		}
+	} else if describeErr != nil {
+		// guards must not be evaluated against some of the changes only
+		res.Err = errors.Wrap(describeErr, "AWS error while running DescribeChangeSet")
 	}
 
 	return
 }
 
 func (s *Deployer) WaitForExecute(stackName *string, changeSet *ChangeSetRecord, stmr streamer.Streameriface) (res *StackRecord) {
-	var err error
-
 	res = &StackRecord{
 		Stack: &cloudformation.Stack{},
 	}
@@ -262,52 +521,75 @@ func (s *Deployer) WaitForExecute(stackName *string, changeSet *ChangeSetRecord,
 
 	s.logger.WithField("stackName", *stackName).Debug("Waiting for stack to be created/updated")
 
-	var wg sync.WaitGroup
-	done := make(chan bool)
+	err, streamErr := s.waitAndStream(stackName, changeSet.EventFilter(), stmr, func() error {
+		if *changeSet.ChangeSetType == cloudformation.ChangeSetTypeCreate {
+			return s.svc.WaitUntilStackCreateComplete(describeStackInput)
+		}
+
+		return s.svc.WaitUntilStackUpdateComplete(describeStackInput)
+	})
 
-	wg.Add(1)
+	res.Stack = s.DescribeStackUnsafe(stackName)
+
+	if streamErr != nil {
+		res.StreamErr = errors.Wrap(streamErr, "error while streaming stack events")
+	}
+
+	if err != nil {
+		res.Err = fmt.Errorf("failed creating/updating stack, status: %s", *res.Stack.StackStatus)
+	}
+
+	return
+}
+
+// waitAndStream runs wait in the background and, when stmr is given, streams
+// stack events until wait has returned. Both results are collected over buffered
+// channels, so neither goroutine can block if the other one finishes first.
+func (s *Deployer) waitAndStream(stackName *string, filter *streamer.EventFilter, stmr streamer.Streameriface, wait func() error) (waitErr error, streamErr error) {
+	waitCh := make(chan error, 1)
+	streamCh := make(chan error, 1)
+	done := make(chan bool)
 
 	go func() {
-		defer wg.Done()
-		if *changeSet.ChangeSetType == cloudformation.ChangeSetTypeCreate {
-			err = s.svc.WaitUntilStackCreateComplete(describeStackInput)
-		} else {
-			err = s.svc.WaitUntilStackUpdateComplete(describeStackInput)
-		}
-		done <- true
+		waitCh <- wait()
+		close(done)
 	}()
 
-	if stmr != nil {
-		s.logger.WithField("stackName", *stackName).Debug("Stream is enabled, preparing to stream stack events")
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			wr := writer.New(os.Stderr, writer.JSONFormatter)
-			stmr.StartStreaming(stackName, changeSet.StackEvents, wr, done)
-		}()
-	} else {
-		<-done
+	if stmr == nil {
+		waitErr = <-waitCh
 		s.logger.WithField("stackName", *stackName).Debug("Stack is ready and no streaming is required")
+		return
 	}
 
-	wg.Wait()
+	s.logger.WithField("stackName", *stackName).Debug("Stream is enabled, preparing to stream stack events")
 
-	res.Stack = s.DescribeStackUnsafe(stackName)
+	go func() {
+		streamCh <- stmr.StartStreaming(stackName, filter, s.eventSink, done)
+	}()
 
-	if err != nil {
-		res.Err = fmt.Errorf("failed creating/updating stack, status: %s", *res.Stack.StackStatus)
+	for pending := 2; pending > 0; pending-- {
+		select {
+		case waitErr = <-waitCh:
+			s.logger.WithField("stackName", *stackName).Debug("Stack is ready, waiting for streaming to finish")
+		case streamErr = <-streamCh:
+			if streamErr != nil {
+				s.logger.WithField("stackName", *stackName).WithError(streamErr).Debug("Streaming has stopped with an error")
+			}
+		}
 	}
 
 	return
 }
 
-func (s *Deployer) ExecuteChangeset(stackName *string, changeSetID *string) error {
+// ExecuteChangeset executes the change set, clientRequestToken is assigned to all events of the operation
+func (s *Deployer) ExecuteChangeset(stackName *string, changeSetID *string, clientRequestToken *string) error {
 
 	s.logger.WithField("stackName", *stackName).Debug("Running ExecuteChangeSet")
 
 	_, err := s.svc.ExecuteChangeSet(&cloudformation.ExecuteChangeSetInput{
-		StackName:     stackName,
-		ChangeSetName: changeSetID,
+		StackName:          stackName,
+		ChangeSetName:      changeSetID,
+		ClientRequestToken: clientRequestToken,
 	})
 
 	if err != nil {
@@ -321,6 +603,78 @@ func (s *Deployer) CreateChangeSet(deployParams *DeployParams) *ChangeSetRecord
 	return s.createChangeSet(deployParams)
 }
 
+// WaitForStable waits until the stack is no longer in one of *_IN_PROGRESS states,
+// streaming its events if stmr is given
+func (s *Deployer) WaitForStable(stackName *string, timeout time.Duration, stmr streamer.Streameriface) (res *StackRecord) {
+	res = &StackRecord{}
+
+	hasStack, stack, err := s.hasStack(stackName)
+	if err != nil {
+		res.Err = err
+		return
+	}
+
+	if !hasStack || !s.isInProgress(stack.StackStatus) {
+		s.logger.WithField("stackName", *stackName).Debug("Stack is stable, no waiting is required")
+		res.Stack = stack
+		return
+	}
+
+	s.logger.WithField("stackName", *stackName).Debug(fmt.Sprintf("Stack is in %s, waiting for it to become stable", *stack.StackStatus))
+
+	waitErr, streamErr := s.waitAndStream(stackName, streamer.OperationFilter(stack), stmr, func() (err error) {
+		res.Stack, err = s.pollUntilStable(stackName, timeout)
+		return
+	})
+
+	if streamErr != nil {
+		res.StreamErr = errors.Wrap(streamErr, "error while streaming stack events")
+	}
+
+	res.Err = waitErr
+
+	return
+}
+
+func (s *Deployer) pollUntilStable(stackName *string, timeout time.Duration) (*cloudformation.Stack, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		hasStack, stack, err := s.hasStack(stackName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasStack || !s.isInProgress(stack.StackStatus) {
+			return stack, nil
+		}
+
+		if timeout > 0 && time.Now().After(deadline) {
+			return stack, &StableTimeoutError{
+				StackName:   *stackName,
+				StackStatus: *stack.StackStatus,
+				Timeout:     timeout,
+			}
+		}
+
+		time.Sleep(s.pollInterval)
+	}
+}
+
+func (s *Deployer) clientRequestToken() (*string, error) {
+	random := make([]byte, 16)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	return aws.String(fmt.Sprintf("%s-%s", s.changesetPrefix, hex.EncodeToString(random))), nil
+}
+
+func (s *Deployer) isInProgress(stackStatus *string) bool {
+	return strings.HasSuffix(*stackStatus, "_IN_PROGRESS") && *stackStatus != cloudformation.StackStatusReviewInProgress
+}
+
 func (s *Deployer) hasFailedCreation(stackStatus *string) bool {
 	return *stackStatus == cloudformation.StackStatusCreateFailed || *stackStatus == cloudformation.StackStatusRollbackComplete
 }
@@ -2,15 +2,17 @@ package deployer_test
 
 import (
 	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/b-b3rn4rd/gocfn/pkg/deployer"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
 	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
 	"github.com/b-b3rn4rd/gocfn/pkg/uploader"
-	"github.com/b-b3rn4rd/gocfn/pkg/writer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -26,29 +28,51 @@ type mockedCloudFormationAPI struct {
 	waitUntilStackDeleteCompleteErr     error
 	waitUntilChangeSetCreateCompleteErr error
 	describeChangeSetOutput             cloudformation.DescribeChangeSetOutput
+	describeChangeSetPages              map[string]cloudformation.DescribeChangeSetOutput
 	describeChangeSetErr                error
 	executeChangeSetOutput              cloudformation.ExecuteChangeSetOutput
 	executeChangeSetErr                 error
+	deleteChangeSetErr                  error
+	deletedChangeSets                   *[]string
+	listChangeSetsPages                 []cloudformation.ListChangeSetsOutput
+	listChangeSetsErr                   error
+	getTemplateOutput                   cloudformation.GetTemplateOutput
+	getTemplateErr                      error
 	cloudformationiface.CloudFormationAPI
 	waitUntilStackCreateCompleteErr error
 	waitUntilStackUpdateCompleteErr error
+	waitDelay                       time.Duration
+	describeStacksSequence          []cloudformation.DescribeStacksOutput
+	describeStacksCalls             *int
 }
 
 type mockedStreamer struct {
 	startStreaming            error
+	returnEarly               bool
 	describeStackEventsOutput streamer.StackEventsRecord
 }
 
-func (s mockedStreamer) StartStreaming(stackName *string, seenEvents streamer.StackEvents, wr *writer.StringWriter, done <-chan bool) error {
-	<-done
+func (s mockedStreamer) StartStreaming(stackName *string, filter *streamer.EventFilter, sink streamer.EventSink, done <-chan bool) error {
+	if !s.returnEarly {
+		<-done
+	}
 	return s.startStreaming
 }
 
-func (s mockedStreamer) DescribeStackEvents(stackName *string, seenEvents streamer.StackEvents) (stackEvents *streamer.StackEventsRecord) {
+func (s mockedStreamer) DescribeStackEvents(stackName *string, filter *streamer.EventFilter, seenEvents streamer.StackEvents) (stackEvents *streamer.StackEventsRecord) {
 	return &s.describeStackEventsOutput
 }
 
 func (m mockedCloudFormationAPI) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	if len(m.describeStacksSequence) > 0 {
+		i := *m.describeStacksCalls
+		if i < len(m.describeStacksSequence)-1 {
+			*m.describeStacksCalls++
+		}
+
+		return &m.describeStacksSequence[i], m.describeStacksErr
+	}
+
 	return &m.describeStacksOutput, m.describeStacksErr
 }
 
@@ -69,6 +93,11 @@ func (m mockedCloudFormationAPI) WaitUntilChangeSetCreateComplete(input *cloudfo
 }
 
 func (m mockedCloudFormationAPI) DescribeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	if m.describeChangeSetPages != nil {
+		page := m.describeChangeSetPages[aws.StringValue(input.NextToken)]
+		return &page, m.describeChangeSetErr
+	}
+
 	return &m.describeChangeSetOutput, m.describeChangeSetErr
 }
 
@@ -76,11 +105,38 @@ func (m mockedCloudFormationAPI) ExecuteChangeSet(input *cloudformation.ExecuteC
 	return &m.executeChangeSetOutput, m.executeChangeSetErr
 }
 
+func (m mockedCloudFormationAPI) DeleteChangeSet(input *cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error) {
+	if m.deletedChangeSets != nil {
+		*m.deletedChangeSets = append(*m.deletedChangeSets, *input.ChangeSetName)
+	}
+
+	return &cloudformation.DeleteChangeSetOutput{}, m.deleteChangeSetErr
+}
+
+func (m mockedCloudFormationAPI) GetTemplate(input *cloudformation.GetTemplateInput) (*cloudformation.GetTemplateOutput, error) {
+	return &m.getTemplateOutput, m.getTemplateErr
+}
+
+func (m mockedCloudFormationAPI) ListChangeSets(input *cloudformation.ListChangeSetsInput) (*cloudformation.ListChangeSetsOutput, error) {
+	if m.listChangeSetsErr != nil {
+		return nil, m.listChangeSetsErr
+	}
+
+	page := 0
+	if input.NextToken != nil {
+		page, _ = strconv.Atoi(*input.NextToken)
+	}
+
+	return &m.listChangeSetsPages[page], nil
+}
+
 func (m mockedCloudFormationAPI) WaitUntilStackCreateComplete(input *cloudformation.DescribeStacksInput) error {
+	time.Sleep(m.waitDelay)
 	return m.waitUntilStackCreateCompleteErr
 }
 
 func (m mockedCloudFormationAPI) WaitUntilStackUpdateComplete(input *cloudformation.DescribeStacksInput) error {
+	time.Sleep(m.waitDelay)
 	return m.waitUntilStackUpdateCompleteErr
 }
 
@@ -210,6 +266,9 @@ func TestCreateChangeSet(t *testing.T) {
 					ChangeSetId: aws.String("test"),
 				},
 				ChangeSetType: aws.String(cloudformation.ChangeSetTypeUpdate),
+				Stack: &cloudformation.Stack{
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				},
 			},
 			createChangeSetOutput: cloudformation.CreateChangeSetOutput{
 				Id: aws.String("test"),
@@ -247,9 +306,12 @@ func TestCreateChangeSet(t *testing.T) {
 				assert.EqualError(t, test.changeSetRecord.Err, resp.Err.Error())
 			}
 
-			assert.Equal(t, test.changeSetRecord.StackEvents, resp.StackEvents)
+			if resp.Err == nil {
+				assert.NotNil(t, resp.ClientRequestToken)
+			}
 			assert.Equal(t, test.changeSetRecord.ChangeSetType, resp.ChangeSetType)
 			assert.Equal(t, test.changeSetRecord.ChangeSet, resp.ChangeSet)
+			assert.Equal(t, test.changeSetRecord.Stack, resp.Stack)
 		})
 	}
 }
@@ -260,14 +322,17 @@ func TestWaitForChangeSet(t *testing.T) {
 		changeSetId                         *string
 		waitUntilChangeSetCreateCompleteErr error
 		describeChangeSetOutput             cloudformation.DescribeChangeSetOutput
+		describeChangeSetPages              map[string]cloudformation.DescribeChangeSetOutput
 		describeChangeSetErr                error
 		// resp
-		changeSetRecord *deployer.ChangeSetRecord
+		changeSetRecord   *deployer.ChangeSetRecord
+		deletedChangeSets []string
 	}{
 		"WaitForChangeSet returns errror if WaitUntilChangeSetCreateComplete has failed with unknown error": {
 			stackName:                           aws.String("test-stack"),
 			changeSetId:                         aws.String("one"),
 			waitUntilChangeSetCreateCompleteErr: errors.New("wait error"),
+			deletedChangeSets:                   []string{},
 			changeSetRecord: &deployer.ChangeSetRecord{
 				Err: errors.Wrap(errors.New("wait error"), "AWS error while running WaitUntilChangeSetCreateComplete"),
 				ChangeSet: &cloudformation.DescribeChangeSetOutput{
@@ -291,10 +356,51 @@ func TestWaitForChangeSet(t *testing.T) {
 			describeChangeSetOutput: cloudformation.DescribeChangeSetOutput{
 				StatusReason: aws.String("The submitted information didn't contain changes."),
 			},
+			deletedChangeSets: []string{"one"},
+		},
+		"WaitForChangeSet collects changes of all DescribeChangeSet pages": {
+			stackName:         aws.String("test-stack"),
+			changeSetId:       aws.String("one"),
+			deletedChangeSets: []string{},
+			changeSetRecord: &deployer.ChangeSetRecord{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetId: aws.String("one"),
+					Changes: []*cloudformation.Change{
+						{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Queue")}},
+						{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Bucket")}},
+					},
+				},
+			},
+			describeChangeSetPages: map[string]cloudformation.DescribeChangeSetOutput{
+				"": {
+					ChangeSetId: aws.String("one"),
+					Changes: []*cloudformation.Change{
+						{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Queue")}},
+					},
+					NextToken: aws.String("page-2"),
+				},
+				"page-2": {
+					ChangeSetId: aws.String("one"),
+					Changes: []*cloudformation.Change{
+						{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Bucket")}},
+					},
+				},
+			},
+		},
+		"WaitForChangeSet returns error if DescribeChangeSet has failed": {
+			stackName:            aws.String("test-stack"),
+			changeSetId:          aws.String("one"),
+			describeChangeSetErr: errors.New("describe error"),
+			deletedChangeSets:    []string{},
+			changeSetRecord: &deployer.ChangeSetRecord{
+				Err:       errors.Wrap(errors.New("describe error"), "AWS error while running DescribeChangeSet"),
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{},
+			},
 		},
 		"WaitForChangeSet fills ChangeSet field with DescribeChangeSet information": {
-			stackName:   aws.String("test-stack"),
-			changeSetId: aws.String("one"),
+			stackName:         aws.String("test-stack"),
+			changeSetId:       aws.String("one"),
+			deletedChangeSets: []string{},
 			changeSetRecord: &deployer.ChangeSetRecord{
 
 				ChangeSet: &cloudformation.DescribeChangeSetOutput{
@@ -312,10 +418,13 @@ func TestWaitForChangeSet(t *testing.T) {
 	}
 
 	for name, test := range tests {
+		deletedChangeSets := []string{}
 		svc := mockedCloudFormationAPI{
 			waitUntilChangeSetCreateCompleteErr: test.waitUntilChangeSetCreateCompleteErr,
 			describeChangeSetOutput:             test.describeChangeSetOutput,
+			describeChangeSetPages:              test.describeChangeSetPages,
 			describeChangeSetErr:                test.describeChangeSetErr,
+			deletedChangeSets:                   &deletedChangeSets,
 		}
 
 		t.Run(name, func(t *testing.T) {
@@ -328,10 +437,46 @@ func TestWaitForChangeSet(t *testing.T) {
 
 			assert.Equal(t, test.changeSetRecord.ChangeSetType, resp.ChangeSetType)
 			assert.Equal(t, test.changeSetRecord.ChangeSet, resp.ChangeSet)
+			assert.Equal(t, test.deletedChangeSets, deletedChangeSets)
 		})
 	}
 }
 
+func TestWaitForChangeSetChecksChangesOfAllPages(t *testing.T) {
+	svc := mockedCloudFormationAPI{
+		describeChangeSetPages: map[string]cloudformation.DescribeChangeSetOutput{
+			"": {
+				ChangeSetName: aws.String("cfn-1"),
+				Changes: []*cloudformation.Change{
+					{ResourceChange: &cloudformation.ResourceChange{
+						Action:            aws.String(cloudformation.ChangeActionAdd),
+						LogicalResourceId: aws.String("Queue"),
+						ResourceType:      aws.String("AWS::SQS::Queue"),
+					}},
+				},
+				NextToken: aws.String("page-2"),
+			},
+			"page-2": {
+				ChangeSetName: aws.String("cfn-1"),
+				Changes: []*cloudformation.Change{
+					{ResourceChange: &cloudformation.ResourceChange{
+						Action:            aws.String(cloudformation.ChangeActionRemove),
+						LogicalResourceId: aws.String("Topic"),
+						ResourceType:      aws.String("AWS::SNS::Topic"),
+					}},
+				},
+			},
+		},
+	}
+
+	d := deployer.New(svc, logrus.New())
+	resp := d.WaitForChangeSet(aws.String("test-stack"), aws.String("one"))
+	assert.NoError(t, resp.Err)
+
+	policy := &guard.Policy{DenyRemoval: true}
+	assert.EqualError(t, policy.Check(resp.ChangeSet), "change set cfn-1 is denied by the deploy policy: Topic (AWS::SNS::Topic) would be removed")
+}
+
 func TestExecuteChangeset(t *testing.T) {
 	tests := map[string]struct {
 		stackName   *string
@@ -363,7 +508,7 @@ func TestExecuteChangeset(t *testing.T) {
 
 		t.Run(name, func(t *testing.T) {
 			d := deployer.New(svc, logrus.New())
-			err := d.ExecuteChangeset(test.stackName, test.changeSetId)
+			err := d.ExecuteChangeset(test.stackName, test.changeSetId, aws.String("token"))
 			if err != nil {
 				assert.EqualError(t, test.err, err.Error())
 			}
@@ -472,3 +617,475 @@ func TestWaitForExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForExecuteWithStreaming(t *testing.T) {
+	tests := map[string]struct {
+		waitUntilStackCreateCompleteErr error
+		waitDelay                       time.Duration
+		stmr                            mockedStreamer
+		//
+		err       error
+		streamErr error
+	}{
+		"Streaming finishes after the stack is created": {
+			waitDelay: 10 * time.Millisecond,
+			stmr:      mockedStreamer{},
+		},
+		"Streaming finishes after the stack creation has failed": {
+			waitUntilStackCreateCompleteErr: errors.New("error occurred"),
+			waitDelay:                       10 * time.Millisecond,
+			stmr:                            mockedStreamer{},
+			err:                             errors.New(fmt.Sprintf("failed creating/updating stack, status: %s", cloudformation.StackStatusCreateComplete)),
+		},
+		"Streaming error is surfaced when streaming stops before the stack is created": {
+			waitDelay: 10 * time.Millisecond,
+			stmr: mockedStreamer{
+				returnEarly:    true,
+				startStreaming: errors.New("throttled"),
+			},
+			streamErr: errors.Wrap(errors.New("throttled"), "error while streaming stack events"),
+		},
+		"Streaming error is surfaced when streaming stops after the stack is created": {
+			stmr: mockedStreamer{
+				startStreaming: errors.New("throttled"),
+			},
+			streamErr: errors.Wrap(errors.New("throttled"), "error while streaming stack events"),
+		},
+		"Both errors are surfaced when streaming stops before the stack creation has failed": {
+			waitUntilStackCreateCompleteErr: errors.New("error occurred"),
+			waitDelay:                       10 * time.Millisecond,
+			stmr: mockedStreamer{
+				returnEarly:    true,
+				startStreaming: errors.New("throttled"),
+			},
+			err:       errors.New(fmt.Sprintf("failed creating/updating stack, status: %s", cloudformation.StackStatusCreateComplete)),
+			streamErr: errors.Wrap(errors.New("throttled"), "error while streaming stack events"),
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			waitUntilStackCreateCompleteErr: test.waitUntilStackCreateCompleteErr,
+			waitDelay:                       test.waitDelay,
+			describeStacksOutput: cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{{
+					StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				}},
+			},
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			res := d.WaitForExecute(aws.String("test-stack"), &deployer.ChangeSetRecord{
+				ChangeSetType: aws.String(cloudformation.ChangeSetTypeCreate),
+			}, test.stmr)
+
+			if test.err != nil {
+				assert.EqualError(t, res.Err, test.err.Error())
+			} else {
+				assert.NoError(t, res.Err)
+			}
+
+			if test.streamErr != nil {
+				assert.EqualError(t, res.StreamErr, test.streamErr.Error())
+			} else {
+				assert.NoError(t, res.StreamErr)
+			}
+		})
+	}
+}
+
+func TestWaitForStable(t *testing.T) {
+	inProgress := cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress),
+		}},
+	}
+
+	complete := cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+		}},
+	}
+
+	tests := map[string]struct {
+		describeStacksSequence []cloudformation.DescribeStacksOutput
+		describeStacksErr      error
+		timeout                time.Duration
+		stmr                   streamer.Streameriface
+		//
+		stack *cloudformation.Stack
+		err   error
+	}{
+		"WaitForStable returns immediately if stack is stable": {
+			describeStacksSequence: []cloudformation.DescribeStacksOutput{complete},
+			stack:                  complete.Stacks[0],
+		},
+		"WaitForStable polls until stack becomes stable": {
+			describeStacksSequence: []cloudformation.DescribeStacksOutput{inProgress, inProgress, complete},
+			stack:                  complete.Stacks[0],
+		},
+		"WaitForStable streams events while waiting": {
+			describeStacksSequence: []cloudformation.DescribeStacksOutput{inProgress, complete},
+			stmr: mockedStreamer{
+				describeStackEventsOutput: streamer.StackEventsRecord{
+					Records: streamer.StackEventList{},
+				},
+			},
+			stack: complete.Stacks[0],
+		},
+		"WaitForStable returns error if stack is not stable in time": {
+			describeStacksSequence: []cloudformation.DescribeStacksOutput{inProgress},
+			timeout:                5 * time.Millisecond,
+			stack:                  inProgress.Stacks[0],
+			err:                    errors.New("stack test-stack is still in UPDATE_IN_PROGRESS after waiting for 5ms"),
+		},
+		"WaitForStable returns error if cant describe stack": {
+			describeStacksSequence: []cloudformation.DescribeStacksOutput{complete},
+			describeStacksErr:      errors.New("cant describe stack error"),
+			err:                    errors.Wrap(errors.New("cant describe stack error"), "AWS error while running DescribeStack"),
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			describeStacksSequence: test.describeStacksSequence,
+			describeStacksErr:      test.describeStacksErr,
+			describeStacksCalls:    new(int),
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New(), deployer.PollInterval(time.Millisecond))
+			res := d.WaitForStable(aws.String("test-stack"), test.timeout, test.stmr)
+
+			if test.err != nil {
+				assert.EqualError(t, res.Err, test.err.Error())
+			} else {
+				assert.NoError(t, res.Err)
+			}
+
+			assert.Equal(t, test.stack, res.Stack)
+		})
+	}
+}
+
+func TestChangeSetRecordEventFilter(t *testing.T) {
+	creationTime := time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+	changeSet := &deployer.ChangeSetRecord{
+		ChangeSet: &cloudformation.DescribeChangeSetOutput{
+			CreationTime: aws.Time(creationTime),
+		},
+		ClientRequestToken: aws.String("token"),
+	}
+
+	assert.Equal(t, &streamer.EventFilter{
+		ClientRequestToken: aws.String("token"),
+		Since:              creationTime,
+	}, changeSet.EventFilter())
+}
+
+func TestDescribeStack(t *testing.T) {
+	tests := map[string]struct {
+		describeStacksOutput cloudformation.DescribeStacksOutput
+		describeStacksErr    error
+		stack                *cloudformation.Stack
+		err                  string
+	}{
+		"DescribeStack returns existing stack": {
+			describeStacksOutput: cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{{
+					StackName:   aws.String("test-stack"),
+					StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+				}},
+			},
+			stack: &cloudformation.Stack{
+				StackName:   aws.String("test-stack"),
+				StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+			},
+		},
+		"DescribeStack returns error if stack does not exist": {
+			describeStacksErr: errors.New("Stack with id test-stack does not exist"),
+			err:               "stack test-stack does not exist",
+		},
+		"DescribeStack returns AWS error": {
+			describeStacksErr: errors.New("throttled"),
+			err:               "AWS error while running DescribeStack: throttled",
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			describeStacksOutput: test.describeStacksOutput,
+			describeStacksErr:    test.describeStacksErr,
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			res := d.DescribeStack(aws.String("test-stack"))
+
+			assert.Equal(t, test.stack, res.Stack)
+
+			if test.err != "" {
+				assert.EqualError(t, res.Err, test.err)
+			} else {
+				assert.NoError(t, res.Err)
+			}
+		})
+	}
+}
+
+func TestDescribeChangeSet(t *testing.T) {
+	tests := map[string]struct {
+		describeChangeSetOutput cloudformation.DescribeChangeSetOutput
+		describeChangeSetErr    error
+		changeSet               *cloudformation.DescribeChangeSetOutput
+		err                     string
+	}{
+		"DescribeChangeSet returns the change set": {
+			describeChangeSetOutput: cloudformation.DescribeChangeSetOutput{
+				ChangeSetId:     aws.String("one"),
+				ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+			},
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				ChangeSetId:     aws.String("one"),
+				ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+			},
+		},
+		"DescribeChangeSet returns AWS error": {
+			describeChangeSetErr: errors.New("ChangeSet one does not exist"),
+			err:                  "AWS error while running DescribeChangeSet: ChangeSet one does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			describeChangeSetOutput: test.describeChangeSetOutput,
+			describeChangeSetErr:    test.describeChangeSetErr,
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			res := d.DescribeChangeSet(aws.String("test-stack"), aws.String("one"))
+
+			assert.Equal(t, test.changeSet, res.ChangeSet)
+
+			if test.err != "" {
+				assert.EqualError(t, res.Err, test.err)
+			} else {
+				assert.NoError(t, res.Err)
+			}
+		})
+	}
+}
+
+func TestExistingChangeSet(t *testing.T) {
+	tests := map[string]struct {
+		describeStacksOutput cloudformation.DescribeStacksOutput
+		describeChangeSetErr error
+		changeSetType        *string
+		err                  string
+	}{
+		"ExistingChangeSet of existing stack is an update": {
+			describeStacksOutput: cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{{StackStatus: aws.String(cloudformation.StackStatusUpdateComplete)}},
+			},
+			changeSetType: aws.String(cloudformation.ChangeSetTypeUpdate),
+		},
+		"ExistingChangeSet of stack in review is a create": {
+			describeStacksOutput: cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{{StackStatus: aws.String(cloudformation.StackStatusReviewInProgress)}},
+			},
+			changeSetType: aws.String(cloudformation.ChangeSetTypeCreate),
+		},
+		"ExistingChangeSet returns AWS error": {
+			describeChangeSetErr: errors.New("ChangeSet one does not exist"),
+			err:                  "AWS error while running DescribeChangeSet: ChangeSet one does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			describeStacksOutput:    test.describeStacksOutput,
+			describeChangeSetOutput: cloudformation.DescribeChangeSetOutput{ChangeSetId: aws.String("one")},
+			describeChangeSetErr:    test.describeChangeSetErr,
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			res := d.ExistingChangeSet(aws.String("test-stack"), aws.String("one"))
+
+			if test.err != "" {
+				assert.EqualError(t, res.Err, test.err)
+				return
+			}
+
+			assert.NoError(t, res.Err)
+			assert.Equal(t, test.changeSetType, res.ChangeSetType)
+			assert.Equal(t, aws.String("one"), res.ChangeSet.ChangeSetId)
+			assert.NotEmpty(t, aws.StringValue(res.ClientRequestToken))
+		})
+	}
+}
+
+func TestDeleteChangeSet(t *testing.T) {
+	d := deployer.New(mockedCloudFormationAPI{}, logrus.New())
+	assert.NoError(t, d.DeleteChangeSet(aws.String("test-stack"), aws.String("one")))
+
+	d = deployer.New(mockedCloudFormationAPI{deleteChangeSetErr: errors.New("throttled")}, logrus.New())
+	assert.EqualError(t, d.DeleteChangeSet(aws.String("test-stack"), aws.String("one")), "AWS error while running DeleteChangeSet: throttled")
+}
+
+func TestListChangeSets(t *testing.T) {
+	tests := map[string]struct {
+		listChangeSetsPages []cloudformation.ListChangeSetsOutput
+		listChangeSetsErr   error
+		summaries           []*cloudformation.ChangeSetSummary
+		err                 string
+	}{
+		"ListChangeSets follows pagination": {
+			listChangeSetsPages: []cloudformation.ListChangeSetsOutput{
+				{
+					Summaries: []*cloudformation.ChangeSetSummary{{ChangeSetName: aws.String("one")}},
+					NextToken: aws.String("1"),
+				},
+				{
+					Summaries: []*cloudformation.ChangeSetSummary{{ChangeSetName: aws.String("two")}},
+				},
+			},
+			summaries: []*cloudformation.ChangeSetSummary{
+				{ChangeSetName: aws.String("one")},
+				{ChangeSetName: aws.String("two")},
+			},
+		},
+		"ListChangeSets returns AWS error": {
+			listChangeSetsErr: errors.New("throttled"),
+			err:               "AWS error while running ListChangeSets: throttled",
+		},
+	}
+
+	for name, test := range tests {
+		svc := mockedCloudFormationAPI{
+			listChangeSetsPages: test.listChangeSetsPages,
+			listChangeSetsErr:   test.listChangeSetsErr,
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			summaries, err := d.ListChangeSets(aws.String("test-stack"))
+
+			assert.Equal(t, test.summaries, summaries)
+
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPruneChangeSets(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		listChangeSetsPages []cloudformation.ListChangeSetsOutput
+		listChangeSetsErr   error
+		deletedChangeSets   []string
+		err                 string
+	}{
+		"PruneChangeSets deletes only stale change sets created by the deployer": {
+			listChangeSetsPages: []cloudformation.ListChangeSetsOutput{{
+				Summaries: []*cloudformation.ChangeSetSummary{
+					{
+						ChangeSetId:   aws.String("arn:stale"),
+						ChangeSetName: aws.String("cfn-cloudformation-package-deploy-1521961972"),
+						CreationTime:  aws.Time(now.Add(-72 * time.Hour)),
+					},
+					{
+						ChangeSetId:   aws.String("arn:recent"),
+						ChangeSetName: aws.String("cfn-cloudformation-package-deploy-1522221172"),
+						CreationTime:  aws.Time(now.Add(-time.Hour)),
+					},
+					{
+						ChangeSetId:   aws.String("arn:manual"),
+						ChangeSetName: aws.String("manual"),
+						CreationTime:  aws.Time(now.Add(-72 * time.Hour)),
+					},
+				},
+			}},
+			deletedChangeSets: []string{"arn:stale"},
+		},
+		"PruneChangeSets does nothing if stack does not exist": {
+			listChangeSetsErr: errors.New("Stack with id test-stack does not exist"),
+			deletedChangeSets: []string{},
+		},
+		"PruneChangeSets returns AWS error": {
+			listChangeSetsErr: errors.New("throttled"),
+			deletedChangeSets: []string{},
+			err:               "AWS error while running ListChangeSets: throttled",
+		},
+	}
+
+	for name, test := range tests {
+		deletedChangeSets := []string{}
+		svc := mockedCloudFormationAPI{
+			listChangeSetsPages: test.listChangeSetsPages,
+			listChangeSetsErr:   test.listChangeSetsErr,
+			deletedChangeSets:   &deletedChangeSets,
+		}
+
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(svc, logrus.New())
+			pruned, err := d.PruneChangeSets(aws.String("test-stack"), 48*time.Hour)
+
+			assert.Equal(t, test.deletedChangeSets, deletedChangeSets)
+			assert.Len(t, pruned, len(test.deletedChangeSets))
+
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	tests := map[string]struct {
+		getTemplateOutput cloudformation.GetTemplateOutput
+		getTemplateErr    error
+		expectedBody      string
+		expectedErr       string
+	}{
+		"GetTemplate returns the template body": {
+			getTemplateOutput: cloudformation.GetTemplateOutput{
+				TemplateBody: aws.String("Resources: {}\n"),
+			},
+			expectedBody: "Resources: {}\n",
+		},
+		"GetTemplate returns error if the template can't be fetched": {
+			getTemplateErr: errors.New("Stack with id hello does not exist"),
+			expectedErr:    "AWS error while running GetTemplate: Stack with id hello does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := deployer.New(mockedCloudFormationAPI{
+				getTemplateOutput: test.getTemplateOutput,
+				getTemplateErr:    test.getTemplateErr,
+			}, logrus.New())
+
+			body, err := d.GetTemplate(aws.String("hello"))
+
+			assert.Equal(t, test.expectedBody, body)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
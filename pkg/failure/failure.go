@@ -0,0 +1,161 @@
+package failure
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+)
+
+// Failure failed resource of a stack operation, Path is a nested stack path of the resource
+type Failure struct {
+	Path                 string `json:",omitempty"`
+	LogicalResourceId    string
+	PhysicalResourceId   string
+	ResourceType         string
+	ResourceStatus       string
+	ResourceStatusReason string
+	Timestamp            time.Time
+}
+
+// Report failed stack operation with its root causes
+type Report struct {
+	StackName   string
+	StackStatus string
+	Failures    []*Failure
+}
+
+// NestedEventsFunc returns events of the nested stack
+type NestedEventsFunc func(stackID *string) (streamer.StackEventList, error)
+
+func (r *Report) Error() string {
+	msg := fmt.Sprintf("failed creating/updating stack, status: %s", r.StackStatus)
+
+	if len(r.Failures) == 0 {
+		return msg
+	}
+
+	causes := make([]string, len(r.Failures))
+	for i, f := range r.Failures {
+		causes[i] = f.String()
+	}
+
+	return fmt.Sprintf("%s, root cause: %s", msg, strings.Join(causes, "; "))
+}
+
+// IsFailedStatus checks if stack status is a result of a failed or rolled back operation
+func IsFailedStatus(stackStatus string) bool {
+	return strings.HasSuffix(stackStatus, "_FAILED") || strings.Contains(stackStatus, "ROLLBACK_")
+}
+
+func (f *Failure) String() string {
+	logicalID := f.LogicalResourceId
+	if f.Path != "" {
+		logicalID = f.Path + "/" + logicalID
+	}
+
+	return fmt.Sprintf("%s (%s) %s: %s", logicalID, f.ResourceType, f.ResourceStatus, f.ResourceStatusReason)
+}
+
+// RootCauses finds the first failed resources of the operation, stackEvents must contain
+// only events of this operation in chronological order. Failures of nested stacks are
+// replaced with failures of their resources when nestedEvents is given
+func RootCauses(stackEvents streamer.StackEventList, nestedEvents NestedEventsFunc) ([]*Failure, error) {
+	return rootCauses("", stackEvents, nestedEvents)
+}
+
+func rootCauses(path string, stackEvents streamer.StackEventList, nestedEvents NestedEventsFunc) ([]*Failure, error) {
+	failures := []*Failure{}
+	stackFailures := []*Failure{}
+
+	for _, e := range stackEvents {
+		status := aws.StringValue(e.ResourceStatus)
+
+		if isStack(e) && strings.HasSuffix(status, "ROLLBACK_IN_PROGRESS") {
+			// everything failing after this point is caused by the rollback
+			break
+		}
+
+		if !strings.HasSuffix(status, "_FAILED") || isCancelled(e) {
+			continue
+		}
+
+		if isStack(e) {
+			stackFailures = append(stackFailures, newFailure(path, e))
+			continue
+		}
+
+		if isNestedStack(e) && nestedEvents != nil {
+			nestedFailures, err := nestedRootCauses(path, e, stackEvents[0].Timestamp, nestedEvents)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(nestedFailures) != 0 {
+				failures = append(failures, nestedFailures...)
+				continue
+			}
+		}
+
+		failures = append(failures, newFailure(path, e))
+	}
+
+	if len(failures) == 0 {
+		return stackFailures, nil
+	}
+
+	return failures, nil
+}
+
+func nestedRootCauses(path string, e *cloudformation.StackEvent, since *time.Time, nestedEvents NestedEventsFunc) ([]*Failure, error) {
+	events, err := nestedEvents(e.PhysicalResourceId)
+	if err != nil {
+		return nil, err
+	}
+
+	operationEvents := streamer.StackEventList{}
+
+	for _, nested := range events {
+		if !aws.TimeValue(nested.Timestamp).Before(aws.TimeValue(since)) {
+			operationEvents = append(operationEvents, nested)
+		}
+	}
+
+	if len(operationEvents) == 0 {
+		return nil, nil
+	}
+
+	nestedPath := aws.StringValue(e.LogicalResourceId)
+	if path != "" {
+		nestedPath = path + "/" + nestedPath
+	}
+
+	return rootCauses(nestedPath, operationEvents, nestedEvents)
+}
+
+func newFailure(path string, e *cloudformation.StackEvent) *Failure {
+	return &Failure{
+		Path:                 path,
+		LogicalResourceId:    aws.StringValue(e.LogicalResourceId),
+		PhysicalResourceId:   aws.StringValue(e.PhysicalResourceId),
+		ResourceType:         aws.StringValue(e.ResourceType),
+		ResourceStatus:       aws.StringValue(e.ResourceStatus),
+		ResourceStatusReason: aws.StringValue(e.ResourceStatusReason),
+		Timestamp:            aws.TimeValue(e.Timestamp),
+	}
+}
+
+func isStack(e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.PhysicalResourceId) == aws.StringValue(e.StackId)
+}
+
+func isNestedStack(e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" && aws.StringValue(e.PhysicalResourceId) != ""
+}
+
+func isCancelled(e *cloudformation.StackEvent) bool {
+	return strings.Contains(aws.StringValue(e.ResourceStatusReason), "cancelled")
+}
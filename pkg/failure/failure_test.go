@@ -0,0 +1,125 @@
+package failure_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/internal/streamertest"
+	"github.com/b-b3rn4rd/gocfn/pkg/failure"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootCauses(t *testing.T) {
+	tests := map[string]struct {
+		stackEvents  streamer.StackEventList
+		nestedEvents map[string]streamer.StackEventList
+		nestedErr    error
+		failures     []string
+		err          error
+	}{
+		"cancelled resources and rollback failures are ignored": {
+			stackEvents: streamer.StackEventList{
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated", 0),
+				streamertest.StackEvent("root", "Bucket", "hello", "AWS::S3::Bucket", "UPDATE_FAILED", "hello already exists", time.Second),
+				streamertest.StackEvent("root", "Queue", "queue", "AWS::SQS::Queue", "UPDATE_FAILED", "Resource update cancelled", time.Second*2),
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_IN_PROGRESS", "The following resource(s) failed to update: [Bucket]", time.Second*3),
+				streamertest.StackEvent("root", "Topic", "topic", "AWS::SNS::Topic", "UPDATE_FAILED", "rollback failure", time.Second*4),
+			},
+			failures: []string{"Bucket (AWS::S3::Bucket) UPDATE_FAILED: hello already exists"},
+		},
+		"nested stack failure is replaced with its resource failures": {
+			stackEvents: streamer.StackEventList{
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated", 0),
+				streamertest.StackEvent("root", "Network", "child", "AWS::CloudFormation::Stack", "CREATE_FAILED", "Embedded stack child was not successfully created", time.Second*5),
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "ROLLBACK_IN_PROGRESS", "The following resource(s) failed to create: [Network]", time.Second*6),
+			},
+			nestedEvents: map[string]streamer.StackEventList{
+				"child": {
+					streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "DELETE_FAILED", "previous operation", -time.Second*10),
+					streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated", time.Second),
+					streamertest.StackEvent("child", "Subnet", "", "AWS::EC2::Subnet", "CREATE_FAILED", "The vpc ID 'vpc-1' does not exist", time.Second*2),
+					streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "ROLLBACK_IN_PROGRESS", "The following resource(s) failed to create: [Subnet]", time.Second*3),
+				},
+			},
+			failures: []string{"Network/Subnet (AWS::EC2::Subnet) CREATE_FAILED: The vpc ID 'vpc-1' does not exist"},
+		},
+		"stack failure is returned if no resources have failed": {
+			stackEvents: streamer.StackEventList{
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated", 0),
+				streamertest.StackEvent("root", "root", "root", "AWS::CloudFormation::Stack", "UPDATE_FAILED", "Parameter validation failed", time.Second),
+			},
+			failures: []string{"root (AWS::CloudFormation::Stack) UPDATE_FAILED: Parameter validation failed"},
+		},
+		"error is returned if nested stack events cant be described": {
+			stackEvents: streamer.StackEventList{
+				streamertest.StackEvent("root", "Network", "child", "AWS::CloudFormation::Stack", "CREATE_FAILED", "Embedded stack child was not successfully created", time.Second*5),
+			},
+			nestedErr: errors.New("describe error"),
+			err:       errors.New("describe error"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			failures, err := failure.RootCauses(test.stackEvents, func(stackID *string) (streamer.StackEventList, error) {
+				return test.nestedEvents[*stackID], test.nestedErr
+			})
+
+			if test.err != nil {
+				assert.EqualError(t, err, test.err.Error())
+				return
+			}
+
+			actual := []string{}
+			for _, f := range failures {
+				actual = append(actual, f.String())
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.failures, actual)
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	report := &failure.Report{
+		StackName:   "hello",
+		StackStatus: "ROLLBACK_COMPLETE",
+	}
+
+	assert.EqualError(t, report, "failed creating/updating stack, status: ROLLBACK_COMPLETE")
+
+	report.Failures = []*failure.Failure{
+		{
+			Path:                 "Network",
+			LogicalResourceId:    "Subnet",
+			ResourceType:         "AWS::EC2::Subnet",
+			ResourceStatus:       "CREATE_FAILED",
+			ResourceStatusReason: "The vpc ID 'vpc-1' does not exist",
+		},
+	}
+
+	assert.EqualError(t, report, "failed creating/updating stack, status: ROLLBACK_COMPLETE, root cause: Network/Subnet (AWS::EC2::Subnet) CREATE_FAILED: The vpc ID 'vpc-1' does not exist")
+}
+
+func TestIsFailedStatus(t *testing.T) {
+	tests := map[string]bool{
+		cloudformation.StackStatusCreateComplete:         false,
+		cloudformation.StackStatusUpdateComplete:         false,
+		cloudformation.StackStatusDeleteComplete:         false,
+		cloudformation.StackStatusCreateFailed:           true,
+		cloudformation.StackStatusDeleteFailed:           true,
+		cloudformation.StackStatusRollbackComplete:       true,
+		cloudformation.StackStatusUpdateRollbackComplete: true,
+		cloudformation.StackStatusUpdateRollbackFailed:   true,
+	}
+
+	for status, expected := range tests {
+		t.Run(status, func(t *testing.T) {
+			assert.Equal(t, expected, failure.IsFailedStatus(status))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package guard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// Guardiface checks the change set before it's executed, error is returned when execution is denied
+type Guardiface interface {
+	Check(changeSet *cloudformation.DescribeChangeSetOutput) error
+}
+
+// Policy denies replacement of resources of DenyReplacement types and, when DenyRemoval is set,
+// removal of any resource. Resources which may be replaced conditionally are treated as replaced
+type Policy struct {
+	DenyReplacement []string
+	DenyRemoval     bool
+}
+
+// Violation resource change denied by the policy
+type Violation struct {
+	LogicalResourceId string
+	ResourceType      string
+	Action            string
+	Replacement       string `json:",omitempty"`
+}
+
+// Report change set denied by the policy with the offending resource changes
+type Report struct {
+	StackName     string
+	ChangeSetId   string
+	ChangeSetName string
+	Violations    []*Violation
+}
+
+func (r *Report) Error() string {
+	violations := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		violations[i] = v.String()
+	}
+
+	return fmt.Sprintf("change set %s is denied by the deploy policy: %s", r.ChangeSetName, strings.Join(violations, "; "))
+}
+
+func (v *Violation) String() string {
+	if v.Action == cloudformation.ChangeActionRemove {
+		return fmt.Sprintf("%s (%s) would be removed", v.LogicalResourceId, v.ResourceType)
+	}
+
+	return fmt.Sprintf("%s (%s) would be replaced, replacement: %s", v.LogicalResourceId, v.ResourceType, v.Replacement)
+}
+
+// Check returns report listing the resource changes denied by the policy, nil is returned when there are none
+func (p *Policy) Check(changeSet *cloudformation.DescribeChangeSetOutput) error {
+	violations := []*Violation{}
+
+	for _, c := range changeSet.Changes {
+		rc := c.ResourceChange
+		if rc == nil || !p.denies(rc) {
+			continue
+		}
+
+		violation := &Violation{
+			LogicalResourceId: aws.StringValue(rc.LogicalResourceId),
+			ResourceType:      aws.StringValue(rc.ResourceType),
+			Action:            aws.StringValue(rc.Action),
+		}
+
+		if violation.Action == cloudformation.ChangeActionModify {
+			violation.Replacement = aws.StringValue(rc.Replacement)
+		}
+
+		violations = append(violations, violation)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &Report{
+		StackName:     aws.StringValue(changeSet.StackName),
+		ChangeSetId:   aws.StringValue(changeSet.ChangeSetId),
+		ChangeSetName: aws.StringValue(changeSet.ChangeSetName),
+		Violations:    violations,
+	}
+}
+
+func (p *Policy) denies(rc *cloudformation.ResourceChange) bool {
+	switch aws.StringValue(rc.Action) {
+	case cloudformation.ChangeActionRemove:
+		return p.DenyRemoval
+	case cloudformation.ChangeActionModify:
+		replacement := aws.StringValue(rc.Replacement)
+		if replacement != cloudformation.ReplacementTrue && replacement != cloudformation.ReplacementConditional {
+			return false
+		}
+
+		for _, resourceType := range p.DenyReplacement {
+			if resourceType == aws.StringValue(rc.ResourceType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
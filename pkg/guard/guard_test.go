@@ -0,0 +1,91 @@
+package guard_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/guard"
+	"github.com/stretchr/testify/assert"
+)
+
+var changeSet = &cloudformation.DescribeChangeSetOutput{
+	StackName:     aws.String("hello"),
+	ChangeSetId:   aws.String("arn:changeset"),
+	ChangeSetName: aws.String("cfn-1"),
+	Changes: []*cloudformation.Change{
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Database"),
+				ResourceType:      aws.String("AWS::RDS::DBInstance"),
+				Replacement:       aws.String(cloudformation.ReplacementConditional),
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Table"),
+				ResourceType:      aws.String("AWS::DynamoDB::Table"),
+				Replacement:       aws.String(cloudformation.ReplacementFalse),
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Bucket"),
+				ResourceType:      aws.String("AWS::S3::Bucket"),
+				Replacement:       aws.String(cloudformation.ReplacementTrue),
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionRemove),
+				LogicalResourceId: aws.String("Queue"),
+				ResourceType:      aws.String("AWS::SQS::Queue"),
+			},
+		},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		policy      *guard.Policy
+		expectedErr string
+	}{
+		"policy without rules allows everything": {
+			policy: &guard.Policy{},
+		},
+		"policy denies replacement of listed resource types": {
+			policy: &guard.Policy{
+				DenyReplacement: []string{"AWS::RDS::DBInstance", "AWS::DynamoDB::Table"},
+			},
+			expectedErr: "change set cfn-1 is denied by the deploy policy: Database (AWS::RDS::DBInstance) would be replaced, replacement: Conditional",
+		},
+		"policy denies removal of any resource": {
+			policy: &guard.Policy{
+				DenyReplacement: []string{"AWS::S3::Bucket"},
+				DenyRemoval:     true,
+			},
+			expectedErr: "change set cfn-1 is denied by the deploy policy: Bucket (AWS::S3::Bucket) would be replaced, replacement: True; Queue (AWS::SQS::Queue) would be removed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.policy.Check(changeSet)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, test.expectedErr)
+
+			report, ok := err.(*guard.Report)
+			assert.True(t, ok)
+			assert.Equal(t, "hello", report.StackName)
+			assert.Equal(t, "arn:changeset", report.ChangeSetId)
+		})
+	}
+}
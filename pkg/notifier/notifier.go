@@ -0,0 +1,170 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Phase of the deployment a notification is sent at
+type Phase string
+
+const (
+	PhaseStarted   Phase = "started"
+	PhaseChangeSet Phase = "changeset"
+	PhaseSucceeded Phase = "succeeded"
+	PhaseNoChanges Phase = "nochanges"
+	PhaseFailed    Phase = "failed"
+)
+
+// Change resource change of the change set
+type Change struct {
+	Action            string
+	LogicalResourceId string
+	ResourceType      string
+	Replacement       string `json:",omitempty"`
+}
+
+// Notification describes a phase of the deployment, Err is set only for PhaseFailed
+type Notification struct {
+	Phase       Phase
+	StackName   string
+	StackStatus string           `json:",omitempty"`
+	ChangeSetId string           `json:",omitempty"`
+	Changes     []*Change        `json:",omitempty"`
+	Summary     *preview.Summary `json:",omitempty"`
+	Err         string           `json:",omitempty"`
+	Timestamp   time.Time
+}
+
+type Notifieriface interface {
+	Notify(*Notification) error
+}
+
+// Webhook posts notifications to the URL, failed requests are retried with exponential backoff
+type Webhook struct {
+	url        string
+	client     *http.Client
+	template   Template
+	maxRetries int
+	retryDelay time.Duration
+	logger     *logrus.Logger
+}
+
+// Timeout sets the time limit of a single request
+func Timeout(timeout time.Duration) func(w *Webhook) {
+	return func(w *Webhook) {
+		w.client.Timeout = timeout
+	}
+}
+
+// MaxRetries sets the maximum number of retries of failed requests
+func MaxRetries(maxRetries int) func(w *Webhook) {
+	return func(w *Webhook) {
+		w.maxRetries = maxRetries
+	}
+}
+
+// RetryDelay sets the delay before the first retry, doubled on each retry
+func RetryDelay(retryDelay time.Duration) func(w *Webhook) {
+	return func(w *Webhook) {
+		w.retryDelay = retryDelay
+	}
+}
+
+// WithTemplate sets the template rendering request body
+func WithTemplate(template Template) func(w *Webhook) {
+	return func(w *Webhook) {
+		w.template = template
+	}
+}
+
+func New(url string, logger *logrus.Logger, options ...func(w *Webhook)) *Webhook {
+	w := &Webhook{
+		url:        url,
+		client:     &http.Client{Timeout: time.Second * 10},
+		template:   GenericTemplate,
+		maxRetries: 3,
+		retryDelay: time.Second,
+		logger:     logger,
+	}
+
+	for _, option := range options {
+		option(w)
+	}
+
+	return w
+}
+
+// ChangeSetChanges lists resource changes of the change set
+func ChangeSetChanges(changeSet *cloudformation.DescribeChangeSetOutput) []*Change {
+	changes := []*Change{}
+
+	for _, c := range changeSet.Changes {
+		if c.ResourceChange == nil {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Action:            aws.StringValue(c.ResourceChange.Action),
+			LogicalResourceId: aws.StringValue(c.ResourceChange.LogicalResourceId),
+			ResourceType:      aws.StringValue(c.ResourceChange.ResourceType),
+			Replacement:       aws.StringValue(c.ResourceChange.Replacement),
+		})
+	}
+
+	return changes
+}
+
+// Notify posts the notification, server errors and network errors are retried
+func (w *Webhook) Notify(n *Notification) error {
+	body, err := w.template(n)
+	if err != nil {
+		return errors.Wrap(err, "error while rendering notification")
+	}
+
+	delay := w.retryDelay
+
+	for retry := 0; ; retry++ {
+		isRetryable, err := w.post(body)
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryable || retry >= w.maxRetries {
+			return errors.Wrap(err, "error while sending notification")
+		}
+
+		w.logger.WithField("phase", n.Phase).WithField("retry", retry+1).WithError(err).Debug("Retrying notification")
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (w *Webhook) post(body []byte) (isRetryable bool, err error) {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("webhook responded with %s", resp.Status)
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
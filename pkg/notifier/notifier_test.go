@@ -0,0 +1,157 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/notifier"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotify(t *testing.T) {
+	tests := map[string]struct {
+		statuses []int
+		delay    time.Duration
+		calls    int64
+		err      bool
+	}{
+		"notification is posted once when webhook succeeds": {
+			statuses: []int{http.StatusOK},
+			calls:    1,
+		},
+		"server errors are retried": {
+			statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent},
+			calls:    3,
+		},
+		"client errors are not retried": {
+			statuses: []int{http.StatusNotFound},
+			calls:    1,
+			err:      true,
+		},
+		"gives up after max retries": {
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			calls:    3,
+			err:      true,
+		},
+		"slow requests time out": {
+			statuses: []int{http.StatusOK},
+			delay:    time.Millisecond * 200,
+			calls:    3,
+			err:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int64
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := atomic.AddInt64(&calls, 1) - 1
+
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				time.Sleep(test.delay)
+				w.WriteHeader(test.statuses[int(i)%len(test.statuses)])
+			}))
+			defer server.Close()
+
+			logger, _ := logrustest.NewNullLogger()
+			ntfr := notifier.New(server.URL, logger,
+				notifier.MaxRetries(2),
+				notifier.RetryDelay(time.Millisecond),
+				notifier.Timeout(time.Millisecond*50),
+			)
+
+			err := ntfr.Notify(&notifier.Notification{
+				Phase:     notifier.PhaseStarted,
+				StackName: "hello",
+			})
+
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.calls, atomic.LoadInt64(&calls))
+		})
+	}
+}
+
+func TestNotifyPostsRenderedTemplate(t *testing.T) {
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	logger, _ := logrustest.NewNullLogger()
+	ntfr := notifier.New(server.URL, logger, notifier.WithTemplate(notifier.SlackTemplate))
+
+	err := ntfr.Notify(&notifier.Notification{
+		Phase:     notifier.PhaseFailed,
+		StackName: "hello",
+		Err:       "failed creating/updating stack",
+	})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"text":"Stack hello deployment has failed","attachments":[{"color":"danger","text":"failed creating/updating stack"}]}`, string(<-bodies))
+}
+
+func TestChangeSetChanges(t *testing.T) {
+	changes := notifier.ChangeSetChanges(&cloudformation.DescribeChangeSetOutput{
+		Changes: []*cloudformation.Change{
+			{
+				ResourceChange: &cloudformation.ResourceChange{
+					Action:            aws.String(cloudformation.ChangeActionModify),
+					LogicalResourceId: aws.String("Bucket"),
+					ResourceType:      aws.String("AWS::S3::Bucket"),
+					Replacement:       aws.String(cloudformation.ReplacementTrue),
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, []*notifier.Change{
+		{
+			Action:            "Modify",
+			LogicalResourceId: "Bucket",
+			ResourceType:      "AWS::S3::Bucket",
+			Replacement:       "True",
+		},
+	}, changes)
+}
+
+func TestGenericTemplate(t *testing.T) {
+	timestamp := time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+	raw, err := notifier.GenericTemplate(&notifier.Notification{
+		Phase:       notifier.PhaseSucceeded,
+		StackName:   "hello",
+		StackStatus: "UPDATE_COMPLETE",
+		Timestamp:   timestamp,
+	})
+
+	assert.NoError(t, err)
+
+	actual := map[string]interface{}{}
+	json.Unmarshal(raw, &actual)
+
+	assert.Equal(t, map[string]interface{}{
+		"Phase":       "succeeded",
+		"StackName":   "hello",
+		"StackStatus": "UPDATE_COMPLETE",
+		"Timestamp":   "2018-03-25T07:12:52Z",
+	}, actual)
+}
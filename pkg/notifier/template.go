@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+)
+
+// Template renders notification into the request body
+type Template func(n *Notification) ([]byte, error)
+
+type slackMessage struct {
+	Text        string             `json:"text"`
+	Attachments []*slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color"`
+	Text  string `json:"text"`
+}
+
+// GenericTemplate renders notification as is
+func GenericTemplate(n *Notification) ([]byte, error) {
+	return json.Marshal(n)
+}
+
+// SlackTemplate renders notification as a Slack incoming webhook message
+func SlackTemplate(n *Notification) ([]byte, error) {
+	msg := &slackMessage{
+		Text: n.String(),
+	}
+
+	switch n.Phase {
+	case PhaseChangeSet:
+		if len(n.Changes) != 0 {
+			lines := make([]string, len(n.Changes))
+			for i, c := range n.Changes {
+				lines[i] = c.String()
+			}
+
+			msg.Attachments = []*slackAttachment{{Color: "#439FE0", Text: strings.Join(lines, "\n")}}
+		}
+	case PhaseSucceeded:
+		if n.StackStatus != "" {
+			msg.Attachments = []*slackAttachment{{Color: "good", Text: n.StackStatus}}
+		}
+	case PhaseFailed:
+		msg.Attachments = []*slackAttachment{{Color: "danger", Text: n.Err}}
+	}
+
+	return json.Marshal(msg)
+}
+
+// String summarises the notification in a single line
+func (n *Notification) String() string {
+	switch n.Phase {
+	case PhaseStarted:
+		return fmt.Sprintf("Deploying stack %s", n.StackName)
+	case PhaseChangeSet:
+		return fmt.Sprintf("Change set of stack %s: %s", n.StackName, n.summary())
+	case PhaseSucceeded:
+		return fmt.Sprintf("Stack %s has been deployed", n.StackName)
+	case PhaseNoChanges:
+		return fmt.Sprintf("Stack %s has no changes to deploy", n.StackName)
+	case PhaseFailed:
+		return fmt.Sprintf("Stack %s deployment has failed", n.StackName)
+	}
+
+	return fmt.Sprintf("Stack %s: %s", n.StackName, n.Phase)
+}
+
+// summary counts changes the same way as the change set table does
+func (n *Notification) summary() string {
+	if n.Summary == nil {
+		return preview.Summary{}.String()
+	}
+
+	return n.Summary.String()
+}
+
+func (c *Change) String() string {
+	s := fmt.Sprintf("%s %s (%s)", c.Action, c.LogicalResourceId, c.ResourceType)
+
+	if c.Replacement == cloudformation.ReplacementTrue || c.Replacement == cloudformation.ReplacementConditional {
+		s += fmt.Sprintf(", replacement: %s", c.Replacement)
+	}
+
+	return s
+}
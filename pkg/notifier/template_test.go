@@ -0,0 +1,61 @@
+package notifier_test
+
+import (
+	"testing"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/notifier"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackTemplate(t *testing.T) {
+	tests := map[string]struct {
+		notification *notifier.Notification
+		expected     string
+	}{
+		"started deployment has no attachments": {
+			notification: &notifier.Notification{
+				Phase:     notifier.PhaseStarted,
+				StackName: "hello",
+			},
+			expected: `{"text":"Deploying stack hello"}`,
+		},
+		"change set lists its changes": {
+			notification: &notifier.Notification{
+				Phase:     notifier.PhaseChangeSet,
+				StackName: "hello",
+				Changes: []*notifier.Change{
+					{Action: "Add", LogicalResourceId: "Queue", ResourceType: "AWS::SQS::Queue"},
+					{Action: "Modify", LogicalResourceId: "Bucket", ResourceType: "AWS::S3::Bucket", Replacement: "True"},
+				},
+				Summary: &preview.Summary{Add: 1, Replace: 1},
+			},
+			expected: `{"text":"Change set of stack hello: 1 to add, 0 to change, 1 to replace, 0 to remove","attachments":[{"color":"#439FE0","text":"Add Queue (AWS::SQS::Queue)\nModify Bucket (AWS::S3::Bucket), replacement: True"}]}`,
+		},
+		"succeeded deployment shows stack status": {
+			notification: &notifier.Notification{
+				Phase:       notifier.PhaseSucceeded,
+				StackName:   "hello",
+				StackStatus: "UPDATE_COMPLETE",
+			},
+			expected: `{"text":"Stack hello has been deployed","attachments":[{"color":"good","text":"UPDATE_COMPLETE"}]}`,
+		},
+		"deployment without changes has no attachments": {
+			notification: &notifier.Notification{
+				Phase:       notifier.PhaseNoChanges,
+				StackName:   "hello",
+				StackStatus: "UPDATE_COMPLETE",
+			},
+			expected: `{"text":"Stack hello has no changes to deploy"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			raw, err := notifier.SlackTemplate(test.notification)
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(raw))
+		})
+	}
+}
@@ -38,6 +38,7 @@ type Packageriface interface {
 	WriteOutput(*string, []byte) error
 	Marshall(string, *Template) ([]byte, error)
 	Open(string) (*Template, error)
+	Parse([]byte) (*Template, error)
 }
 
 // PackageParams parameters required for package params
@@ -0,0 +1,134 @@
+package packager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Parse parses YAML or JSON template body into the template, unlike Open short form intrinsic
+// functions such as !Ref or !GetAtt are expanded into their long form, so that the same template
+// written in either form and in either format results in the same template
+func (p *Packager) Parse(body []byte) (*Template, error) {
+	p.logger.Debug("parsing cfn template")
+
+	data := bytes.TrimSpace(body)
+
+	if !bytes.HasPrefix(data, []byte("{")) {
+		node := &yamlv3.Node{}
+		if err := yamlv3.Unmarshal(body, node); err != nil {
+			return nil, errors.Wrap(err, "error while parsing yaml")
+		}
+
+		value, err := nodeValue(node)
+		if err != nil {
+			return nil, errors.Wrap(err, "error while parsing yaml")
+		}
+
+		if data, err = json.Marshal(value); err != nil {
+			return nil, errors.Wrap(err, "error while converting yaml to json")
+		}
+	}
+
+	template := &Template{}
+
+	if err := json.Unmarshal(data, template); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshalling cfn")
+	}
+
+	return template, nil
+}
+
+// nodeValue converts YAML node into JSON compatible value, tagged nodes are converted into intrinsic functions
+func nodeValue(node *yamlv3.Node) (interface{}, error) {
+	if isIntrinsicTag(node.Tag) {
+		return intrinsicValue(node)
+	}
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return nodeValue(node.Content[0])
+	case yamlv3.AliasNode:
+		return nodeValue(node.Alias)
+	case yamlv3.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+
+		for i, n := range node.Content {
+			item, err := nodeValue(n)
+			if err != nil {
+				return nil, err
+			}
+
+			items[i] = item
+		}
+
+		return items, nil
+	case yamlv3.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := nodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			m[node.Content[i].Value] = value
+		}
+
+		return m, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// intrinsicValue converts node tagged with short form intrinsic function, e.g. !Ref Bucket,
+// into its long form, e.g. {"Ref": "Bucket"}
+func intrinsicValue(node *yamlv3.Node) (interface{}, error) {
+	name := strings.TrimPrefix(node.Tag, "!")
+
+	untagged := *node
+	untagged.Tag = ""
+
+	if untagged.Kind == yamlv3.ScalarNode {
+		untagged.Tag = "!!str"
+	}
+
+	arg, err := nodeValue(&untagged)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while parsing !%s", name)
+	}
+
+	switch name {
+	case "Ref", "Condition":
+		return map[string]interface{}{name: arg}, nil
+	case "GetAtt":
+		if s, ok := arg.(string); ok {
+			parts := strings.SplitN(s, ".", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("!GetAtt %s is not in LogicalId.Attribute form", s)
+			}
+
+			arg = []interface{}{parts[0], parts[1]}
+		}
+	}
+
+	return map[string]interface{}{"Fn::" + name: arg}, nil
+}
+
+// isIntrinsicTag checks if tag is a local tag, e.g. !Ref, rather than a standard one, e.g. !!str
+func isIntrinsicTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!") && len(tag) > 1
+}
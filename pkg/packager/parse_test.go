@@ -0,0 +1,86 @@
+package packager_test
+
+import (
+	"testing"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	test2 "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const longFormJSON = `{
+  "Parameters": {"Env": {"Type": "String", "Default": "dev"}},
+  "Resources": {
+    "Bucket": {
+      "Type": "AWS::S3::Bucket",
+      "Properties": {
+        "BucketName": {"Fn::Sub": "${AWS::StackName}-${Env}"},
+        "Tags": [{"Key": "env", "Value": {"Ref": "Env"}}]
+      }
+    },
+    "Policy": {
+      "Type": "AWS::S3::BucketPolicy",
+      "Condition": "IsProd",
+      "Properties": {
+        "Bucket": {"Ref": "Bucket"},
+        "PolicyDocument": {
+          "Statement": [{
+            "Effect": "Deny",
+            "Resource": {"Fn::Join": ["", [{"Fn::GetAtt": ["Bucket", "Arn"]}, "/*"]]}
+          }]
+        }
+      }
+    }
+  },
+  "Outputs": {"Arn": {"Value": {"Fn::If": ["IsProd", {"Fn::GetAtt": ["Bucket", "Arn"]}, {"Ref": "AWS::NoValue"}]}}}
+}`
+
+const shortFormYAML = `
+Outputs:
+  Arn:
+    Value: !If [IsProd, !GetAtt Bucket.Arn, !Ref "AWS::NoValue"]
+Resources:
+  Policy:
+    Properties:
+      PolicyDocument:
+        Statement:
+          - Resource: !Join
+              - ''
+              - - !GetAtt Bucket.Arn
+                - /*
+            Effect: Deny
+      Bucket: !Ref Bucket
+    Condition: IsProd
+    Type: AWS::S3::BucketPolicy
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      Tags:
+        - Key: env
+          Value: !Ref Env
+      BucketName: !Sub "${AWS::StackName}-${Env}"
+Parameters:
+  Env:
+    Default: dev
+    Type: String
+`
+
+func TestParse(t *testing.T) {
+	logger, _ := test2.NewNullLogger()
+	pkgr := packager.New(logger, afero.NewMemMapFs())
+
+	fromJSON, err := pkgr.Parse([]byte(longFormJSON))
+	assert.NoError(t, err)
+
+	fromYAML, err := pkgr.Parse([]byte(shortFormYAML))
+	assert.NoError(t, err)
+
+	assert.Equal(t, fromJSON, fromYAML)
+
+	_, err = pkgr.Parse([]byte("Resources:\n  Bucket: !GetAtt Bucket\n"))
+	assert.EqualError(t, err, "error while parsing yaml: !GetAtt Bucket is not in LogicalId.Attribute form")
+
+	_, err = pkgr.Parse([]byte("Resources: [\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Version of the plan file format
+const Version = 1
+
+// noEchoValue is returned by CloudFormation instead of values of NoEcho parameters
+const noEchoValue = "****"
+
+// Plan reviewed change set waiting to be applied. StackLastUpdatedTime is the stack's
+// LastUpdatedTime when the plan was created, nil for new or never updated stacks.
+// Parameters are informational, the change set keeps their values
+type Plan struct {
+	Version              int
+	StackName            string
+	StackId              string
+	ChangeSetId          string
+	ChangeSetType        string
+	ClientRequestToken   string
+	TemplateHash         string
+	Parameters           []*cloudformation.Parameter
+	StackLastUpdatedTime *time.Time `json:",omitempty"`
+	CreatedAt            time.Time
+}
+
+// TemplateHash calculates sha256 checksum of the template file
+func TemplateHash(appFs afero.Fs, filename string) (string, error) {
+	raw, err := afero.ReadFile(appFs, filename)
+	if err != nil {
+		return "", errors.Wrap(err, "error while reading template")
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RedactNoEcho returns parameters with values of NoEcho parameters of the change set replaced by
+// UsePreviousValue, so that secrets aren't written into the plan
+func RedactNoEcho(parameters []*cloudformation.Parameter, changeSetParameters []*cloudformation.Parameter) []*cloudformation.Parameter {
+	noEcho := map[string]bool{}
+
+	for _, p := range changeSetParameters {
+		if aws.StringValue(p.ParameterValue) == noEchoValue {
+			noEcho[aws.StringValue(p.ParameterKey)] = true
+		}
+	}
+
+	var redacted []*cloudformation.Parameter
+
+	for _, p := range parameters {
+		if noEcho[aws.StringValue(p.ParameterKey)] && !aws.BoolValue(p.UsePreviousValue) {
+			p = &cloudformation.Parameter{ParameterKey: p.ParameterKey, UsePreviousValue: aws.Bool(true)}
+		}
+
+		redacted = append(redacted, p)
+	}
+
+	return redacted
+}
+
+// Write writes plan into the file readable by the owner only, overwriting existing one
+func Write(appFs afero.Fs, filename string, p *Plan) error {
+	raw, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "error while marshalling plan")
+	}
+
+	err = afero.WriteFile(appFs, filename, append(raw, '\n'), 0600)
+	if err != nil {
+		return errors.Wrap(err, "error while writing plan")
+	}
+
+	// WriteFile keeps the mode of the overwritten file
+	err = appFs.Chmod(filename, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error while writing plan")
+	}
+
+	return nil
+}
+
+// Read reads plan from the file, plans written by other versions of the format are rejected
+func Read(appFs afero.Fs, filename string) (*Plan, error) {
+	raw, err := afero.ReadFile(appFs, filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading plan")
+	}
+
+	p := &Plan{}
+
+	err = json.Unmarshal(raw, p)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while unmarshalling plan")
+	}
+
+	if p.Version != Version {
+		return nil, fmt.Errorf("plan version %d is not supported, expected %d", p.Version, Version)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,85 @@
+package plan_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/plan"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateHash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "stack.yml", []byte("Resources: {}\n"), 0644)
+
+	hash, err := plan.TemplateHash(fs, "stack.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "49cbd1e9008e8b67dec76613bb4d6468c822c99701820526928d43b382f35fba", hash)
+
+	_, err = plan.TemplateHash(fs, "missing.yml")
+	assert.Error(t, err)
+}
+
+func TestWriteAndRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	lastUpdatedTime := time.Date(2018, 3, 25, 7, 12, 52, 0, time.UTC)
+
+	p := &plan.Plan{
+		Version:            plan.Version,
+		StackName:          "hello",
+		StackId:            "arn:hello",
+		ChangeSetId:        "arn:changeset",
+		ChangeSetType:      cloudformation.ChangeSetTypeUpdate,
+		ClientRequestToken: "token",
+		TemplateHash:       "hash",
+		Parameters: []*cloudformation.Parameter{
+			{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+		},
+		StackLastUpdatedTime: &lastUpdatedTime,
+		CreatedAt:            lastUpdatedTime.Add(time.Hour),
+	}
+
+	afero.WriteFile(fs, "plan.json", []byte("{}"), 0644)
+	assert.NoError(t, plan.Write(fs, "plan.json", p))
+
+	fi, err := fs.Stat("plan.json")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	actual, err := plan.Read(fs, "plan.json")
+	assert.NoError(t, err)
+	assert.Equal(t, p, actual)
+}
+
+func TestReadRejectsOtherVersions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "plan.json", []byte(`{"Version": 2}`), 0644)
+
+	_, err := plan.Read(fs, "plan.json")
+	assert.EqualError(t, err, "plan version 2 is not supported, expected 1")
+}
+
+func TestRedactNoEcho(t *testing.T) {
+	parameters := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+		{ParameterKey: aws.String("Password"), ParameterValue: aws.String("secret")},
+		{ParameterKey: aws.String("Token"), UsePreviousValue: aws.Bool(true)},
+	}
+
+	changeSetParameters := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+		{ParameterKey: aws.String("Password"), ParameterValue: aws.String("****")},
+		{ParameterKey: aws.String("Token"), ParameterValue: aws.String("****")},
+	}
+
+	assert.Equal(t, []*cloudformation.Parameter{
+		{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+		{ParameterKey: aws.String("Password"), UsePreviousValue: aws.Bool(true)},
+		{ParameterKey: aws.String("Token"), UsePreviousValue: aws.Bool(true)},
+	}, plan.RedactNoEcho(parameters, changeSetParameters))
+	assert.Equal(t, "secret", aws.StringValue(parameters[1].ParameterValue))
+}
@@ -0,0 +1,140 @@
+package preview
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
+)
+
+// maskedValue is returned by CloudFormation instead of values of NoEcho parameters
+const maskedValue = "****"
+
+// Difference change of the stack parameter or tag, values of NoEcho parameters are masked
+type Difference struct {
+	Action   string
+	Key      string
+	OldValue string `json:",omitempty"`
+	NewValue string `json:",omitempty"`
+}
+
+// ChangeSet change set with differences of parameters, tags and, when requested, the template against the deployed stack
+type ChangeSet struct {
+	*cloudformation.DescribeChangeSetOutput
+	ParameterChanges []*Difference          `json:",omitempty"`
+	TagChanges       []*Difference          `json:",omitempty"`
+	TemplateChanges  []*templatediff.Change `json:",omitempty"`
+}
+
+// Diff compares effective parameters and tags of the change set with the deployed stack, stack is nil
+// when the change set creates it. Parameters with UsePreviousValue are unchanged, NoEcho parameters given
+// explicitly are always modified as their deployed values can't be compared. Tags aren't compared when
+// none are given, the change set keeps tags of the stack then
+func Diff(stack *cloudformation.Stack, changeSet *cloudformation.DescribeChangeSetOutput, parameters []*cloudformation.Parameter, tags []*cloudformation.Tag) *ChangeSet {
+	deployedParameters := map[string]string{}
+	deployedTags := map[string]string{}
+
+	if stack != nil {
+		for _, p := range stack.Parameters {
+			deployedParameters[aws.StringValue(p.ParameterKey)] = aws.StringValue(p.ParameterValue)
+		}
+
+		for _, t := range stack.Tags {
+			deployedTags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+	}
+
+	noEcho := map[string]bool{}
+
+	for key, value := range deployedParameters {
+		noEcho[key] = value == maskedValue
+	}
+
+	if changeSet != nil {
+		for _, p := range changeSet.Parameters {
+			if aws.StringValue(p.ParameterValue) == maskedValue {
+				noEcho[aws.StringValue(p.ParameterKey)] = true
+			}
+		}
+	}
+
+	effectiveParameters := map[string]string{}
+
+	for _, p := range parameters {
+		if !aws.BoolValue(p.UsePreviousValue) {
+			effectiveParameters[aws.StringValue(p.ParameterKey)] = aws.StringValue(p.ParameterValue)
+		}
+	}
+
+	cs := &ChangeSet{
+		DescribeChangeSetOutput: changeSet,
+		ParameterChanges:        differences(deployedParameters, effectiveParameters, false, noEcho),
+	}
+
+	if len(tags) > 0 {
+		effectiveTags := map[string]string{}
+
+		for _, t := range tags {
+			effectiveTags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+
+		cs.TagChanges = differences(deployedTags, effectiveTags, true, nil)
+	}
+
+	return cs
+}
+
+// differences lists keys added, modified and, when removal is true, removed in effective sorted by key,
+// values of masked keys are replaced
+func differences(deployed map[string]string, effective map[string]string, removal bool, masked map[string]bool) []*Difference {
+	keys := []string{}
+
+	for key := range effective {
+		keys = append(keys, key)
+	}
+
+	for key := range deployed {
+		if _, ok := effective[key]; !ok && removal {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	diffs := []*Difference{}
+
+	for _, key := range keys {
+		oldValue, deployedOk := deployed[key]
+		newValue, effectiveOk := effective[key]
+
+		d := &Difference{Key: key, OldValue: oldValue, NewValue: newValue}
+
+		switch {
+		case !deployedOk:
+			d.Action = cloudformation.ChangeActionAdd
+		case !effectiveOk:
+			d.Action = cloudformation.ChangeActionRemove
+		case oldValue != newValue || masked[key]:
+			d.Action = cloudformation.ChangeActionModify
+		default:
+			continue
+		}
+
+		if masked[key] {
+			d.OldValue, d.NewValue = mask(d.OldValue), mask(d.NewValue)
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
+
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return maskedValue
+}
@@ -0,0 +1,90 @@
+package preview_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	stack := &cloudformation.Stack{
+		Parameters: []*cloudformation.Parameter{
+			{ParameterKey: aws.String("InstanceType"), ParameterValue: aws.String("t3.small")},
+			{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+			{ParameterKey: aws.String("Password"), ParameterValue: aws.String("****")},
+			{ParameterKey: aws.String("VpcId"), ParameterValue: aws.String("vpc-1")},
+		},
+		Tags: []*cloudformation.Tag{
+			{Key: aws.String("env"), Value: aws.String("dev")},
+			{Key: aws.String("team"), Value: aws.String("platform")},
+		},
+	}
+
+	tests := map[string]struct {
+		stack                    *cloudformation.Stack
+		changeSet                *cloudformation.DescribeChangeSetOutput
+		parameters               []*cloudformation.Parameter
+		tags                     []*cloudformation.Tag
+		expectedParameterChanges []*preview.Difference
+		expectedTagChanges       []*preview.Difference
+	}{
+		"diff lists modified, added and masked parameters": {
+			stack: stack,
+			parameters: []*cloudformation.Parameter{
+				{ParameterKey: aws.String("InstanceType"), ParameterValue: aws.String("m5.large")},
+				{ParameterKey: aws.String("BucketName"), ParameterValue: aws.String("helloza")},
+				{ParameterKey: aws.String("Password"), ParameterValue: aws.String("secret")},
+				{ParameterKey: aws.String("Subnet"), ParameterValue: aws.String("subnet-1")},
+				{ParameterKey: aws.String("VpcId"), UsePreviousValue: aws.Bool(true)},
+			},
+			expectedParameterChanges: []*preview.Difference{
+				{Action: "Modify", Key: "InstanceType", OldValue: "t3.small", NewValue: "m5.large"},
+				{Action: "Modify", Key: "Password", OldValue: "****", NewValue: "****"},
+				{Action: "Add", Key: "Subnet", NewValue: "subnet-1"},
+			},
+		},
+		"diff masks new NoEcho parameters masked by the change set": {
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				Parameters: []*cloudformation.Parameter{
+					{ParameterKey: aws.String("Password"), ParameterValue: aws.String("****")},
+				},
+			},
+			parameters: []*cloudformation.Parameter{
+				{ParameterKey: aws.String("Password"), ParameterValue: aws.String("secret")},
+			},
+			expectedParameterChanges: []*preview.Difference{
+				{Action: "Add", Key: "Password", NewValue: "****"},
+			},
+		},
+		"diff lists modified, added and removed tags": {
+			stack: stack,
+			tags: []*cloudformation.Tag{
+				{Key: aws.String("env"), Value: aws.String("prod")},
+				{Key: aws.String("owner"), Value: aws.String("ops")},
+			},
+			expectedParameterChanges: []*preview.Difference{},
+			expectedTagChanges: []*preview.Difference{
+				{Action: "Modify", Key: "env", OldValue: "dev", NewValue: "prod"},
+				{Action: "Add", Key: "owner", NewValue: "ops"},
+				{Action: "Remove", Key: "team", OldValue: "platform"},
+			},
+		},
+		"diff skips tags when none are given": {
+			stack:                    stack,
+			expectedParameterChanges: []*preview.Difference{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cs := preview.Diff(test.stack, test.changeSet, test.parameters, test.tags)
+
+			assert.Equal(t, test.changeSet, cs.DescribeChangeSetOutput)
+			assert.Equal(t, test.expectedParameterChanges, cs.ParameterChanges)
+			assert.Equal(t, test.expectedTagChanges, cs.TagChanges)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package preview
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+// Summary number of resources affected by the change set, replaced resources aren't counted as changed
+type Summary struct {
+	Add     int
+	Change  int
+	Replace int
+	Remove  int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d to add, %d to change, %d to replace, %d to remove", s.Add, s.Change, s.Replace, s.Remove)
+}
+
+// Summarize counts resource changes of the change set, resources which may be replaced
+// are counted as replaced
+func Summarize(changeSet *cloudformation.DescribeChangeSetOutput) Summary {
+	summary := Summary{}
+
+	for _, rc := range resourceChanges(changeSet) {
+		switch aws.StringValue(rc.Action) {
+		case cloudformation.ChangeActionAdd:
+			summary.Add++
+		case cloudformation.ChangeActionRemove:
+			summary.Remove++
+		case cloudformation.ChangeActionModify:
+			if isReplacement(rc) {
+				summary.Replace++
+			} else {
+				summary.Change++
+			}
+		}
+	}
+
+	return summary
+}
+
+// TableFormatter returns a formatter that writes change set as a table with a line per resource change
+// followed by parameter and tag differences, the template diff and the summary, actions and replacements
+// are colour-coded when colored is true
+func TableFormatter(colored bool) writer.FormatFunc {
+	return func(wr io.Writer, message interface{}) {
+		var changeSet *cloudformation.DescribeChangeSetOutput
+		var parameterChanges, tagChanges []*Difference
+		var templateChanges []*templatediff.Change
+
+		switch m := message.(type) {
+		case *cloudformation.DescribeChangeSetOutput:
+			changeSet = m
+		case *ChangeSet:
+			changeSet, parameterChanges, tagChanges = m.DescribeChangeSetOutput, m.ParameterChanges, m.TagChanges
+			templateChanges = m.TemplateChanges
+		default:
+			writer.PlainFormatter(wr, message)
+			return
+		}
+
+		changes := resourceChanges(changeSet)
+
+		if len(changes) == 0 && len(parameterChanges) == 0 && len(tagChanges) == 0 && len(templateChanges) == 0 {
+			fmt.Fprintf(wr, "Change set %s contains no changes\n", aws.StringValue(changeSet.ChangeSetName))
+			return
+		}
+
+		if len(changes) == 0 {
+			// parameter and tag differences are still worth showing, e.g. a changed parameter nothing refers to
+			fmt.Fprintf(wr, "Change set %s contains no resource changes\n", aws.StringValue(changeSet.ChangeSetName))
+		} else {
+			writeLine(wr, fmt.Sprintf("%-8s", "Action"), "LogicalId", "Type", fmt.Sprintf("%-11s", "Replacement"), "Scope", "CausingEntities")
+		}
+
+		for _, rc := range changes {
+			action := fmt.Sprintf("%-8s", aws.StringValue(rc.Action))
+			replacement := fmt.Sprintf("%-11s", replacementOf(rc))
+
+			if colored {
+				action = colorizeAction(aws.StringValue(rc.Action), action)
+
+				if isReplacement(rc) {
+					replacement = writer.Colorize(replacement, writer.ColorRed)
+				}
+			}
+
+			writeLine(wr,
+				action,
+				aws.StringValue(rc.LogicalResourceId),
+				aws.StringValue(rc.ResourceType),
+				replacement,
+				strings.Join(aws.StringValueSlice(rc.Scope), ","),
+				strings.Join(causingEntities(rc), ","),
+			)
+		}
+
+		writeDifferences(wr, "Parameter", parameterChanges, colored)
+		writeDifferences(wr, "Tag", tagChanges, colored)
+
+		if len(templateChanges) > 0 {
+			fmt.Fprintln(wr)
+			templatediff.Write(wr, templateChanges, colored)
+		}
+
+		fmt.Fprintf(wr, "\nPlan: %s\n", Summarize(changeSet))
+	}
+}
+
+// writeDifferences writes a line per difference, modified values are written as old -> new
+func writeDifferences(wr io.Writer, title string, diffs []*Difference, colored bool) {
+	if len(diffs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(wr, "\n%-8s  %-30s  %s\n", "Action", title, "Value")
+
+	for _, d := range diffs {
+		action := fmt.Sprintf("%-8s", d.Action)
+		if colored {
+			action = colorizeAction(d.Action, action)
+		}
+
+		value := d.NewValue
+
+		switch d.Action {
+		case cloudformation.ChangeActionModify:
+			value = fmt.Sprintf("%s -> %s", d.OldValue, d.NewValue)
+		case cloudformation.ChangeActionRemove:
+			value = d.OldValue
+		}
+
+		line := fmt.Sprintf("%s  %-30s  %s", action, d.Key, value)
+		fmt.Fprintln(wr, strings.TrimRight(line, " "))
+	}
+}
+
+func writeLine(wr io.Writer, action, logicalID, resourceType, replacement, scope, causingEntities string) {
+	line := fmt.Sprintf("%s  %-30s  %-40s  %s  %-20s  %s", action, logicalID, resourceType, replacement, scope, causingEntities)
+	fmt.Fprintln(wr, strings.TrimRight(line, " "))
+}
+
+func resourceChanges(changeSet *cloudformation.DescribeChangeSetOutput) []*cloudformation.ResourceChange {
+	changes := []*cloudformation.ResourceChange{}
+
+	for _, c := range changeSet.Changes {
+		if c.ResourceChange != nil {
+			changes = append(changes, c.ResourceChange)
+		}
+	}
+
+	return changes
+}
+
+// replacementOf is empty for added and removed resources, replacement doesn't apply to them
+func replacementOf(rc *cloudformation.ResourceChange) string {
+	if aws.StringValue(rc.Action) != cloudformation.ChangeActionModify {
+		return ""
+	}
+
+	return aws.StringValue(rc.Replacement)
+}
+
+func isReplacement(rc *cloudformation.ResourceChange) bool {
+	replacement := replacementOf(rc)
+
+	return replacement == cloudformation.ReplacementTrue || replacement == cloudformation.ReplacementConditional
+}
+
+// causingEntities lists unique entities which have caused the change, e.g. parameters or other resources
+func causingEntities(rc *cloudformation.ResourceChange) []string {
+	unique := map[string]bool{}
+
+	for _, d := range rc.Details {
+		if d.CausingEntity != nil {
+			unique[*d.CausingEntity] = true
+		}
+	}
+
+	entities := make([]string, 0, len(unique))
+	for entity := range unique {
+		entities = append(entities, entity)
+	}
+
+	sort.Strings(entities)
+
+	return entities
+}
+
+func colorizeAction(action string, text string) string {
+	color := writer.ColorYellow
+
+	switch action {
+	case cloudformation.ChangeActionAdd:
+		color = writer.ColorGreen
+	case cloudformation.ChangeActionRemove:
+		color = writer.ColorRed
+	}
+
+	return writer.Colorize(text, color)
+}
@@ -0,0 +1,147 @@
+package preview_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/preview"
+	"github.com/stretchr/testify/assert"
+)
+
+var changeSet = &cloudformation.DescribeChangeSetOutput{
+	ChangeSetName: aws.String("cfn-1"),
+	Changes: []*cloudformation.Change{
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionAdd),
+				LogicalResourceId: aws.String("Queue"),
+				ResourceType:      aws.String("AWS::SQS::Queue"),
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Bucket"),
+				ResourceType:      aws.String("AWS::S3::Bucket"),
+				Replacement:       aws.String(cloudformation.ReplacementTrue),
+				Scope:             aws.StringSlice([]string{"Properties"}),
+				Details: []*cloudformation.ResourceChangeDetail{
+					{CausingEntity: aws.String("BucketName")},
+					{CausingEntity: aws.String("BucketName")},
+				},
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Function"),
+				ResourceType:      aws.String("AWS::Lambda::Function"),
+				Replacement:       aws.String(cloudformation.ReplacementFalse),
+				Scope:             aws.StringSlice([]string{"Properties", "Tags"}),
+				Details: []*cloudformation.ResourceChangeDetail{
+					{CausingEntity: aws.String("Role.Arn")},
+					{CausingEntity: aws.String("Bucket")},
+				},
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionRemove),
+				LogicalResourceId: aws.String("Topic"),
+				ResourceType:      aws.String("AWS::SNS::Topic"),
+			},
+		},
+	},
+}
+
+func TestSummarize(t *testing.T) {
+	summary := preview.Summarize(changeSet)
+
+	assert.Equal(t, preview.Summary{Add: 1, Change: 1, Replace: 1, Remove: 1}, summary)
+	assert.Equal(t, "1 to add, 1 to change, 1 to replace, 1 to remove", summary.String())
+}
+
+func TestTableFormatter(t *testing.T) {
+	tests := map[string]struct {
+		colored   bool
+		changeSet interface{}
+		expected  string
+	}{
+		"change set is rendered as table with summary": {
+			changeSet: changeSet,
+			expected: "Action    LogicalId                       Type                                      Replacement  Scope                 CausingEntities\n" +
+				"Add       Queue                           AWS::SQS::Queue\n" +
+				"Modify    Bucket                          AWS::S3::Bucket                           True         Properties            BucketName\n" +
+				"Modify    Function                        AWS::Lambda::Function                     False        Properties,Tags       Bucket,Role.Arn\n" +
+				"Remove    Topic                           AWS::SNS::Topic\n" +
+				"\nPlan: 1 to add, 1 to change, 1 to replace, 1 to remove\n",
+		},
+		"replacement is highlighted when colored": {
+			colored: true,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				Changes: changeSet.Changes[1:2],
+			},
+			expected: "Action    LogicalId                       Type                                      Replacement  Scope                 CausingEntities\n" +
+				"\x1b[33mModify  \x1b[0m  Bucket                          AWS::S3::Bucket                           \x1b[31mTrue       \x1b[0m  Properties            BucketName\n" +
+				"\nPlan: 0 to add, 0 to change, 1 to replace, 0 to remove\n",
+		},
+		"parameter and tag differences are rendered before summary": {
+			changeSet: &preview.ChangeSet{
+				DescribeChangeSetOutput: &cloudformation.DescribeChangeSetOutput{
+					Changes: changeSet.Changes[2:3],
+				},
+				ParameterChanges: []*preview.Difference{
+					{Action: "Modify", Key: "InstanceType", OldValue: "t3.small", NewValue: "m5.large"},
+					{Action: "Add", Key: "Password", NewValue: "****"},
+				},
+				TagChanges: []*preview.Difference{
+					{Action: "Remove", Key: "team", OldValue: "platform"},
+				},
+			},
+			expected: "Action    LogicalId                       Type                                      Replacement  Scope                 CausingEntities\n" +
+				"Modify    Function                        AWS::Lambda::Function                     False        Properties,Tags       Bucket,Role.Arn\n" +
+				"\nAction    Parameter                       Value\n" +
+				"Modify    InstanceType                    t3.small -> m5.large\n" +
+				"Add       Password                        ****\n" +
+				"\nAction    Tag                             Value\n" +
+				"Remove    team                            platform\n" +
+				"\nPlan: 0 to add, 1 to change, 0 to replace, 0 to remove\n",
+		},
+		"empty change set": {
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				ChangeSetName: aws.String("cfn-1"),
+			},
+			expected: "Change set cfn-1 contains no changes\n",
+		},
+		"parameter and tag differences are rendered without resource changes": {
+			changeSet: &preview.ChangeSet{
+				DescribeChangeSetOutput: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetName: aws.String("cfn-1"),
+				},
+				ParameterChanges: []*preview.Difference{
+					{Action: "Modify", Key: "InstanceType", OldValue: "t3.small", NewValue: "m5.large"},
+				},
+				TagChanges: []*preview.Difference{
+					{Action: "Add", Key: "team", NewValue: "platform"},
+				},
+			},
+			expected: "Change set cfn-1 contains no resource changes\n" +
+				"\nAction    Parameter                       Value\n" +
+				"Modify    InstanceType                    t3.small -> m5.large\n" +
+				"\nAction    Tag                             Value\n" +
+				"Add       team                            platform\n" +
+				"\nPlan: 0 to add, 0 to change, 0 to replace, 0 to remove\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			preview.TableFormatter(test.colored)(out, test.changeSet)
+
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// JUnit writes the operation as a JUnit XML report, with a testcase for the change set
+// and a testcase for each resource touched by the operation
+type JUnit struct {
+	filename string
+	appFs    afero.Fs
+	logger   *logrus.Logger
+}
+
+func NewJUnit(filename string, logger *logrus.Logger, appFs afero.Fs) *JUnit {
+	return &JUnit{
+		filename: filename,
+		appFs:    appFs,
+		logger:   logger,
+	}
+}
+
+// Report writes the report file, overwriting existing one
+func (j *JUnit) Report(op *Operation) error {
+	j.logger.WithField("stackName", op.StackName).WithField("filename", j.filename).Debug("Writing JUnit report")
+
+	suite := &junitTestSuite{
+		Name:      op.StackName,
+		Time:      seconds(op.EndTime.Sub(op.StartTime)),
+		Timestamp: op.StartTime.UTC().Format("2006-01-02T15:04:05"),
+		TestCases: j.resourceTestCases(op),
+	}
+
+	for _, tc := range suite.TestCases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	// the change set testcase carries the error unless a resource is already blamed for it
+	changeSet := j.changeSetTestCase(op, suite.Failures == 0)
+	if changeSet.Failure != nil {
+		suite.Failures++
+	}
+
+	suite.TestCases = append([]*junitTestCase{changeSet}, suite.TestCases...)
+	suite.Tests = len(suite.TestCases)
+
+	raw, err := xml.MarshalIndent(&junitTestSuites{TestSuites: []*junitTestSuite{suite}}, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "error while marshalling JUnit report")
+	}
+
+	err = afero.WriteFile(j.appFs, j.filename, append([]byte(xml.Header), append(raw, '\n')...), 0644)
+	if err != nil {
+		return errors.Wrap(err, "error while writing JUnit report")
+	}
+
+	return nil
+}
+
+func (j *JUnit) changeSetTestCase(op *Operation, withErr bool) *junitTestCase {
+	tc := &junitTestCase{
+		Name:      "ChangeSet",
+		ClassName: op.StackName,
+		Time:      seconds(0),
+	}
+
+	if op.ChangeSet != nil {
+		tc.Name = fmt.Sprintf("ChangeSet %s", aws.StringValue(op.ChangeSet.ChangeSetName))
+	}
+
+	if op.Err != nil && withErr {
+		tc.Failure = &junitFailure{
+			Message:  op.Err.Error(),
+			Type:     "error",
+			Contents: op.Err.Error(),
+		}
+	}
+
+	return tc
+}
+
+func (j *JUnit) resourceTestCases(op *Operation) []*junitTestCase {
+	testCases := []*junitTestCase{}
+	byLogicalID := map[string]*junitTestCase{}
+	firstEvent := map[string]time.Time{}
+
+	for _, e := range op.Events {
+		if isStackEvent(e) {
+			continue
+		}
+
+		logicalID := aws.StringValue(e.LogicalResourceId)
+
+		tc, exists := byLogicalID[logicalID]
+		if !exists {
+			tc = &junitTestCase{
+				Name:      logicalID,
+				ClassName: aws.StringValue(e.ResourceType),
+			}
+			byLogicalID[logicalID] = tc
+			firstEvent[logicalID] = aws.TimeValue(e.Timestamp)
+			testCases = append(testCases, tc)
+		}
+
+		tc.Time = seconds(aws.TimeValue(e.Timestamp).Sub(firstEvent[logicalID]))
+
+		status := aws.StringValue(e.ResourceStatus)
+		if tc.Failure == nil && strings.HasSuffix(status, "_FAILED") {
+			tc.Failure = &junitFailure{
+				Message:  aws.StringValue(e.ResourceStatusReason),
+				Type:     status,
+				Contents: aws.StringValue(e.ResourceStatusReason),
+			}
+		}
+	}
+
+	return testCases
+}
+
+// isStackEvent checks if event belongs to the stack itself rather than to its resource
+func isStackEvent(e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" && aws.StringValue(e.PhysicalResourceId) == aws.StringValue(e.StackId)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
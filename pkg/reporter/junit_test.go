@@ -0,0 +1,86 @@
+package reporter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/internal/streamertest"
+	"github.com/b-b3rn4rd/gocfn/pkg/reporter"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/pkg/errors"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitReport(t *testing.T) {
+	stackEvent := streamertest.StackEvent("arn:hello", "hello", "arn:hello", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated", 0)
+
+	tests := map[string]struct {
+		op       *reporter.Operation
+		expected string
+	}{
+		"failed resources are reported with their reason": {
+			op: &reporter.Operation{
+				StackName: "hello",
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					ChangeSetName: aws.String("cfn-1"),
+				},
+				ClientRequestToken: "token",
+				Events: streamer.StackEventList{
+					stackEvent,
+					streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Second),
+					streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::S3::Bucket", "UPDATE_IN_PROGRESS", "", time.Second),
+					streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_COMPLETE", "", time.Second*3),
+					streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::S3::Bucket", "UPDATE_FAILED", "helloza already exists", time.Second*5),
+				},
+				StartTime: streamertest.Timestamp,
+				EndTime:   streamertest.Timestamp.Add(time.Minute),
+				Err:       errors.New("failed creating/updating stack"),
+			},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+    <testsuite name="hello" tests="3" failures="1" time="60.000" timestamp="2018-03-25T07:12:52">
+        <testcase name="ChangeSet cfn-1" classname="hello" time="0.000"></testcase>
+        <testcase name="Queue" classname="AWS::SQS::Queue" time="2.000"></testcase>
+        <testcase name="Bucket" classname="AWS::S3::Bucket" time="4.000">
+            <failure message="helloza already exists" type="UPDATE_FAILED">helloza already exists</failure>
+        </testcase>
+    </testsuite>
+</testsuites>
+`,
+		},
+		"change set failure is reported when no resource has failed": {
+			op: &reporter.Operation{
+				StackName: "hello",
+				StartTime: streamertest.Timestamp,
+				EndTime:   streamertest.Timestamp.Add(time.Second),
+				Err:       errors.New("changeSet creation error"),
+			},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+    <testsuite name="hello" tests="1" failures="1" time="1.000" timestamp="2018-03-25T07:12:52">
+        <testcase name="ChangeSet" classname="hello" time="0.000">
+            <failure message="changeSet creation error" type="error">changeSet creation error</failure>
+        </testcase>
+    </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			logger, _ := logrustest.NewNullLogger()
+
+			err := reporter.NewJUnit("report.xml", logger, fs).Report(test.op)
+			assert.NoError(t, err)
+
+			raw, _ := afero.ReadFile(fs, "report.xml")
+			assert.Equal(t, test.expected, string(raw))
+		})
+	}
+}
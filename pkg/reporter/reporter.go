@@ -0,0 +1,30 @@
+package reporter
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+)
+
+// Operation outcome of the deployment. ChangeSet is nil when the change set couldn't be created,
+// Events are events of the executed change set in chronological order, empty when it wasn't executed
+type Operation struct {
+	StackName          string
+	ChangeSet          *cloudformation.DescribeChangeSetOutput
+	ClientRequestToken string
+	Stack              *cloudformation.Stack
+	Events             streamer.StackEventList
+	StartTime          time.Time
+	EndTime            time.Time
+	Err                error
+}
+
+type Reporteriface interface {
+	Report(*Operation) error
+}
+
+// IsExecuted checks if the change set of the operation has been executed
+func (o *Operation) IsExecuted() bool {
+	return o.ClientRequestToken != ""
+}
@@ -0,0 +1,30 @@
+package reporter
+
+import (
+	"github.com/b-b3rn4rd/gocfn/pkg/timings"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+// Timings writes timing analysis of the executed change set
+type Timings struct {
+	wr      *writer.StringWriter
+	slowest int
+}
+
+func NewTimings(wr *writer.StringWriter, slowest int) *Timings {
+	return &Timings{
+		wr:      wr,
+		slowest: slowest,
+	}
+}
+
+// Report writes the analysis unless the change set hasn't been executed
+func (t *Timings) Report(op *Operation) error {
+	if !op.IsExecuted() {
+		return nil
+	}
+
+	t.wr.Write(timings.Analyze(op.StackName, op.Events, t.slowest))
+
+	return nil
+}
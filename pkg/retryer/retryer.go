@@ -0,0 +1,93 @@
+package retryer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/sirupsen/logrus"
+)
+
+// Retryer retries throttled and retryable AWS requests using
+// exponential backoff with jitter, every retry is logged at debug level
+type Retryer struct {
+	NumMaxRetries int
+	MinDelay      time.Duration
+	MaxDelay      time.Duration
+	logger        *logrus.Logger
+}
+
+// Validate checks retry settings, negative values would make delays negative
+func Validate(maxRetries int, minDelay time.Duration, maxDelay time.Duration) error {
+	switch {
+	case maxRetries < 0:
+		return fmt.Errorf("max retries must not be negative, got %d", maxRetries)
+	case minDelay < 0:
+		return fmt.Errorf("retry min delay must not be negative, got %s", minDelay)
+	case maxDelay < 0:
+		return fmt.Errorf("retry max delay must not be negative, got %s", maxDelay)
+	}
+
+	return nil
+}
+
+// New creates a new Retryer struct
+func New(logger *logrus.Logger, maxRetries int, minDelay time.Duration, maxDelay time.Duration) *Retryer {
+	return &Retryer{
+		NumMaxRetries: maxRetries,
+		MinDelay:      minDelay,
+		MaxDelay:      maxDelay,
+		logger:        logger,
+	}
+}
+
+// MaxRetries returns the number of maximum retries the service will use to make an individual API request
+func (r *Retryer) MaxRetries() int {
+	return r.NumMaxRetries
+}
+
+// ShouldRetry returns true if the request should be retried
+func (r *Retryer) ShouldRetry(req *request.Request) bool {
+	if req.Retryable != nil {
+		return *req.Retryable
+	}
+
+	if req.HTTPResponse != nil && req.HTTPResponse.StatusCode >= 500 {
+		return true
+	}
+
+	return req.IsErrorRetryable() || req.IsErrorThrottle()
+}
+
+// RetryRules returns the delay before the next retry, the delay grows exponentially
+// from MinDelay and is capped by MaxDelay, the actual value is picked randomly from its upper half
+func (r *Retryer) RetryRules(req *request.Request) time.Duration {
+	delay := r.MaxDelay
+
+	backoff := float64(r.MinDelay) * math.Pow(2, float64(req.RetryCount))
+	if backoff < float64(r.MaxDelay) {
+		delay = time.Duration(backoff)
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	f := logrus.Fields{
+		"service":    req.ClientInfo.ServiceName,
+		"retryCount": req.RetryCount + 1,
+		"delay":      delay.String(),
+	}
+
+	if req.Operation != nil {
+		f["operation"] = req.Operation.Name
+	}
+
+	r.logger.WithFields(f).WithError(req.Error).Debug("Retrying AWS request")
+
+	return delay
+}
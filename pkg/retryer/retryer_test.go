@@ -0,0 +1,186 @@
+package retryer_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/b-b3rn4rd/gocfn/pkg/retryer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRetry(t *testing.T) {
+	tests := map[string]struct {
+		req      *request.Request
+		expected bool
+	}{
+		"throttled DescribeStackEvents is retried": {
+			req: &request.Request{
+				Error:        awserr.New("Throttling", "Rate exceeded", nil),
+				HTTPResponse: &http.Response{StatusCode: 400},
+			},
+			expected: true,
+		},
+		"throttled S3 request is retried": {
+			req: &request.Request{
+				Error:        awserr.New("SlowDown", "Please reduce your request rate", nil),
+				HTTPResponse: &http.Response{StatusCode: 503},
+			},
+			expected: true,
+		},
+		"server error is retried": {
+			req: &request.Request{
+				Error:        awserr.New("InternalFailure", "", nil),
+				HTTPResponse: &http.Response{StatusCode: 500},
+			},
+			expected: true,
+		},
+		"validation error is not retried": {
+			req: &request.Request{
+				Error:        awserr.New("ValidationError", "Stack with id hello does not exist", nil),
+				HTTPResponse: &http.Response{StatusCode: 400},
+			},
+			expected: false,
+		},
+		"explicitly not retryable request is not retried": {
+			req: &request.Request{
+				Error:        awserr.New("Throttling", "Rate exceeded", nil),
+				HTTPResponse: &http.Response{StatusCode: 400},
+				Retryable:    aws.Bool(false),
+			},
+			expected: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := logrustest.NewNullLogger()
+			r := retryer.New(logger, 5, time.Millisecond, time.Second)
+
+			assert.Equal(t, test.expected, r.ShouldRetry(test.req))
+		})
+	}
+}
+
+func TestRetryRules(t *testing.T) {
+	tests := map[string]struct {
+		retryCount int
+		minDelay   time.Duration
+		maxDelay   time.Duration
+		min        time.Duration
+		max        time.Duration
+	}{
+		"first retry is delayed by up to min delay": {
+			retryCount: 0,
+			minDelay:   100 * time.Millisecond,
+			maxDelay:   10 * time.Second,
+			min:        50 * time.Millisecond,
+			max:        100 * time.Millisecond,
+		},
+		"delay grows exponentially": {
+			retryCount: 3,
+			minDelay:   100 * time.Millisecond,
+			maxDelay:   10 * time.Second,
+			min:        400 * time.Millisecond,
+			max:        800 * time.Millisecond,
+		},
+		"delay is capped by max delay": {
+			retryCount: 20,
+			minDelay:   100 * time.Millisecond,
+			maxDelay:   10 * time.Second,
+			min:        5 * time.Second,
+			max:        10 * time.Second,
+		},
+		"min delay above max delay is capped by max delay": {
+			retryCount: 0,
+			minDelay:   2 * time.Second,
+			maxDelay:   time.Second,
+			min:        500 * time.Millisecond,
+			max:        time.Second,
+		},
+		"zero delays retry straight away": {
+			retryCount: 3,
+			minDelay:   0,
+			maxDelay:   0,
+			min:        0,
+			max:        0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, hook := logrustest.NewNullLogger()
+			logger.SetLevel(logrus.DebugLevel)
+			r := retryer.New(logger, 5, test.minDelay, test.maxDelay)
+
+			delay := r.RetryRules(&request.Request{
+				Error:      awserr.New("Throttling", "Rate exceeded", nil),
+				RetryCount: test.retryCount,
+				ClientInfo: metadata.ClientInfo{ServiceName: "cloudformation"},
+				Operation:  &request.Operation{Name: "DescribeStackEvents"},
+			})
+
+			assert.True(t, delay >= test.min, "delay %s is less than %s", delay, test.min)
+			assert.True(t, delay <= test.max, "delay %s is greater than %s", delay, test.max)
+			assert.Equal(t, "DescribeStackEvents", hook.LastEntry().Data["operation"])
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		maxRetries int
+		minDelay   time.Duration
+		maxDelay   time.Duration
+		err        error
+	}{
+		"default settings are valid": {
+			maxRetries: 10,
+			minDelay:   500 * time.Millisecond,
+			maxDelay:   30 * time.Second,
+		},
+		"zero settings disable retries and delays": {
+			maxRetries: 0,
+			minDelay:   0,
+			maxDelay:   0,
+		},
+		"negative max retries are rejected": {
+			maxRetries: -1,
+			minDelay:   500 * time.Millisecond,
+			maxDelay:   30 * time.Second,
+			err:        errors.New("max retries must not be negative, got -1"),
+		},
+		"negative min delay is rejected": {
+			maxRetries: 10,
+			minDelay:   -time.Second,
+			maxDelay:   30 * time.Second,
+			err:        errors.New("retry min delay must not be negative, got -1s"),
+		},
+		"negative max delay is rejected": {
+			maxRetries: 10,
+			minDelay:   500 * time.Millisecond,
+			maxDelay:   -time.Second,
+			err:        errors.New("retry max delay must not be negative, got -1s"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := retryer.Validate(test.maxRetries, test.minDelay, test.maxDelay)
+
+			if test.err != nil {
+				assert.EqualError(t, err, test.err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
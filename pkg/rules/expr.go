@@ -0,0 +1,546 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// condition boolean expression evaluated against a document
+type condition interface {
+	holds(e *env) bool
+}
+
+// operand path or literal of a comparison, evaluating to a set of values
+type operand interface {
+	values(e *env) []interface{}
+}
+
+// env document the expression is evaluated against, variables are looked up by their $name
+type env struct {
+	doc  interface{}
+	vars map[string]interface{}
+}
+
+type (
+	orCondition  []condition
+	andCondition []condition
+	notCondition struct{ c condition }
+
+	existsCondition struct{ path *fieldPath }
+	truthyCondition struct{ o operand }
+
+	comparison struct {
+		left  operand
+		op    string
+		right operand
+		re    *regexp.Regexp
+	}
+
+	literal struct{ value interface{} }
+
+	fieldPath struct {
+		text     string
+		segments []segment
+	}
+
+	segment struct {
+		key      string
+		index    int
+		isIndex  bool
+		wildcard bool
+	}
+)
+
+func (c orCondition) holds(e *env) bool {
+	for _, cond := range c {
+		if cond.holds(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c andCondition) holds(e *env) bool {
+	for _, cond := range c {
+		if !cond.holds(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c notCondition) holds(e *env) bool {
+	return !c.c.holds(e)
+}
+
+func (c existsCondition) holds(e *env) bool {
+	for _, v := range c.path.values(e) {
+		if v != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c truthyCondition) holds(e *env) bool {
+	values := c.o.values(e)
+	if len(values) == 0 {
+		return false
+	}
+
+	for _, v := range values {
+		if fmt.Sprint(v) != "true" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// holds checks that every left value satisfies the comparison with at least one right value,
+// wildcard paths which don't match anything satisfy any comparison
+func (c *comparison) holds(e *env) bool {
+	right := c.right.values(e)
+
+	for _, l := range c.left.values(e) {
+		satisfied := false
+
+		for _, r := range right {
+			if c.compare(l, r) {
+				satisfied = true
+				break
+			}
+		}
+
+		if !satisfied {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *comparison) compare(l interface{}, r interface{}) bool {
+	switch c.op {
+	case "==":
+		return equal(l, r)
+	case "!=":
+		return !equal(l, r)
+	case "=~":
+		return l != nil && c.re.MatchString(fmt.Sprint(l))
+	case "!~":
+		return l == nil || !c.re.MatchString(fmt.Sprint(l))
+	case "contains":
+		switch v := l.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if equal(item, r) {
+					return true
+				}
+			}
+		case string:
+			return r != nil && strings.Contains(v, fmt.Sprint(r))
+		}
+	}
+
+	return false
+}
+
+func (l literal) values(e *env) []interface{} {
+	return []interface{}{l.value}
+}
+
+// values resolves the path, missing keys resolve to nil unless they are under a wildcard
+func (p *fieldPath) values(e *env) []interface{} {
+	segments := p.segments
+	current := []interface{}{e.doc}
+
+	if strings.HasPrefix(p.segments[0].key, "$") {
+		current = []interface{}{e.vars[p.segments[0].key]}
+		segments = segments[1:]
+	}
+
+	for _, s := range segments {
+		next := []interface{}{}
+
+		for _, v := range current {
+			switch {
+			case s.wildcard:
+				switch items := v.(type) {
+				case []interface{}:
+					next = append(next, items...)
+				case map[string]interface{}:
+					for _, item := range items {
+						next = append(next, item)
+					}
+				}
+			case s.isIndex:
+				items, _ := v.([]interface{})
+				if s.index < len(items) {
+					next = append(next, items[s.index])
+				} else {
+					next = append(next, nil)
+				}
+			default:
+				m, _ := v.(map[string]interface{})
+				next = append(next, m[s.key])
+			}
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+func equal(l interface{}, r interface{}) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+
+	return fmt.Sprint(l) == fmt.Sprint(r)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPath
+	tokenString
+	tokenNumber
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// parseExpr parses expression such as
+// Properties.SecurityGroupIngress[*].CidrIp != '0.0.0.0/0' && exists(Properties.VpcId)
+func parseExpr(src string) (condition, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+
+	return cond, nil
+}
+
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexRune(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case strings.ContainsRune("=!&|", c):
+			op := string(c)
+			if i+1 < len(src) && strings.ContainsRune("=~&|", rune(src[i+1])) {
+				op = src[i : i+2]
+			}
+
+			switch op {
+			case "==", "!=", "=~", "!~", "&&", "||", "!":
+			default:
+				return nil, fmt.Errorf("unknown operator %q at position %d", op, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		case c == '-' || unicode.IsDigit(c):
+			start := i
+			for i++; i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.'); i++ {
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start})
+		case isPathRune(c):
+			start := i
+			for ; i < len(src) && isPathRune(rune(src[i])); i++ {
+			}
+
+			tokens = append(tokens, token{kind: tokenPath, text: src[start:i], pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isPathRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_$.[]*:", c)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) parseOr() (condition, error) {
+	cond, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	conds := orCondition{cond}
+
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.next()
+
+		cond, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+
+	return conds, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	cond, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	conds := andCondition{cond}
+
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.next()
+
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+
+	return conds, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if t := p.peek(); t.kind == tokenOp && t.text == "!" {
+		p.next()
+
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return notCondition{cond}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+	t := p.peek()
+
+	if t.kind == tokenLParen {
+		p.next()
+
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at position %d", t.pos)
+		}
+
+		return cond, nil
+	}
+
+	if t.kind == tokenPath && t.text == "exists" && p.tokens[p.pos+1].kind == tokenLParen {
+		p.next()
+		p.next()
+
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		pth, ok := arg.(*fieldPath)
+		if !ok {
+			return nil, fmt.Errorf("exists expects a path at position %d", t.pos)
+		}
+
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at position %d", t.pos)
+		}
+
+		return existsCondition{pth}, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t = p.peek()
+
+	isComparison := t.kind == tokenOp && t.text != "&&" && t.text != "||" && t.text != "!"
+	if !isComparison && !(t.kind == tokenPath && t.text == "contains") {
+		return truthyCondition{left}, nil
+	}
+
+	p.next()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &comparison{left: left, op: t.text, right: right}
+
+	if c.op == "=~" || c.op == "!~" {
+		lit, ok := right.(literal)
+		pattern, isString := lit.value.(string)
+
+		if !ok || !isString {
+			return nil, fmt.Errorf("%s expects a string pattern at position %d", c.op, t.pos)
+		}
+
+		if c.re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern at position %d: %s", t.pos, err)
+		}
+	}
+
+	return c, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return literal{t.text}, nil
+	case tokenNumber:
+		if _, err := strconv.ParseFloat(t.text, 64); err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+
+		return literal{t.text}, nil
+	case tokenPath:
+		switch t.text {
+		case "true", "false":
+			return literal{t.text == "true"}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+
+		return parsePath(t)
+	}
+
+	if t.kind == tokenEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+// parsePath parses path such as Properties.Tags[*].Key or Properties.Subnets[0]
+func parsePath(t token) (*fieldPath, error) {
+	p := &fieldPath{text: t.text}
+
+	for _, part := range strings.Split(t.text, ".") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid path %q at position %d", t.text, t.pos)
+		}
+
+		key := part
+		brackets := ""
+
+		if i := strings.IndexRune(part, '['); i >= 0 {
+			key, brackets = part[:i], part[i:]
+		}
+
+		if key != "" {
+			p.segments = append(p.segments, segment{key: key})
+		}
+
+		for brackets != "" {
+			end := strings.IndexRune(brackets, ']')
+			if brackets[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid path %q at position %d", t.text, t.pos)
+			}
+
+			s := segment{wildcard: brackets[1:end] == "*"}
+
+			if !s.wildcard {
+				index, err := strconv.Atoi(brackets[1:end])
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index in path %q at position %d", t.text, t.pos)
+				}
+
+				s.index, s.isIndex = index, true
+			}
+
+			p.segments = append(p.segments, s)
+			brackets = brackets[end+1:]
+		}
+
+	}
+
+	return p, nil
+}
@@ -0,0 +1,306 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+const (
+	// TargetResource rules are evaluated against resources of the template
+	TargetResource = "Resource"
+	// TargetChange rules are evaluated against resource changes of the change set
+	TargetChange = "Change"
+)
+
+// Rule asserts that every resource or resource change of Type, a glob such as AWS::IAM::*, satisfies
+// Assert, unless When is given and it isn't satisfied. Labels passed to the check are available as $labels
+type Rule struct {
+	Name        string
+	Description string `json:",omitempty"`
+	Target      string `json:",omitempty"`
+	Type        string `json:",omitempty"`
+	When        string `json:",omitempty"`
+	Assert      string
+
+	when   condition
+	assert condition
+}
+
+// RuleSet rules loaded from the rule file
+type RuleSet struct {
+	Rules []*Rule
+}
+
+// CheckParams parameters required for check command, change set rules are skipped
+// when change set name isn't given
+type CheckParams struct {
+	RuleSet       *RuleSet
+	TemplateFile  string
+	StackName     string
+	ChangeSetName string
+	Labels        []string
+}
+
+// Violation resource or resource change which doesn't satisfy the rule
+type Violation struct {
+	Rule              string
+	Description       string `json:",omitempty"`
+	Target            string
+	LogicalResourceId string
+	ResourceType      string
+}
+
+// Report violations of the rule set
+type Report struct {
+	Violations []*Violation
+}
+
+func (r *Report) Error() string {
+	violations := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		violations[i] = v.String()
+	}
+
+	return fmt.Sprintf("rules are violated: %s", strings.Join(violations, "; "))
+}
+
+func (v *Violation) String() string {
+	msg := fmt.Sprintf("%s: %s (%s)", v.Rule, v.LogicalResourceId, v.ResourceType)
+	if v.Description != "" {
+		msg = fmt.Sprintf("%s %s", msg, v.Description)
+	}
+
+	return msg
+}
+
+// Load reads rule set from YAML or JSON rule file
+func Load(appFs afero.Fs, filename string) (*RuleSet, error) {
+	raw, err := afero.ReadFile(appFs, filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading rules")
+	}
+
+	return Parse(raw)
+}
+
+// Parse parses rule set and its expressions
+func Parse(raw []byte) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+
+	if err := yaml.Unmarshal(raw, ruleSet); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshalling rules")
+	}
+
+	for i, rule := range ruleSet.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+
+		switch rule.Target {
+		case "":
+			rule.Target = TargetResource
+		case TargetResource, TargetChange:
+		default:
+			return nil, fmt.Errorf("rule %s has unknown target %s, expected %s or %s", rule.Name, rule.Target, TargetResource, TargetChange)
+		}
+
+		if _, err := path.Match(rule.Type, ""); err != nil {
+			return nil, errors.Wrapf(err, "rule %s has invalid type", rule.Name)
+		}
+
+		if rule.Assert == "" {
+			return nil, fmt.Errorf("rule %s has no assertion", rule.Name)
+		}
+
+		var err error
+
+		if rule.assert, err = parseExpr(rule.Assert); err != nil {
+			return nil, errors.Wrapf(err, "rule %s has invalid assertion", rule.Name)
+		}
+
+		if rule.When != "" {
+			if rule.when, err = parseExpr(rule.When); err != nil {
+				return nil, errors.Wrapf(err, "rule %s has invalid condition", rule.Name)
+			}
+		}
+	}
+
+	return ruleSet, nil
+}
+
+// Evaluate checks resources of the template and resource changes of the change set against the rules,
+// either of them can be nil
+func (rs *RuleSet) Evaluate(template *packager.Template, changeSet *cloudformation.DescribeChangeSetOutput, labels []string) ([]*Violation, error) {
+	violations := []*Violation{}
+
+	vars := map[string]interface{}{
+		"$labels": toInterfaces(labels),
+	}
+
+	resources, err := templateResources(template)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := resourceChanges(changeSet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rs.Rules {
+		docs := resources
+		if rule.Target == TargetChange {
+			docs = changes
+		}
+
+		for _, doc := range docs {
+			if !rule.matches(doc, vars) {
+				violations = append(violations, &Violation{
+					Rule:              rule.Name,
+					Description:       rule.Description,
+					Target:            rule.Target,
+					LogicalResourceId: doc.logicalID,
+					ResourceType:      doc.resourceType,
+				})
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// matches checks if document satisfies the rule, documents of other types always satisfy it
+func (r *Rule) matches(doc *document, vars map[string]interface{}) bool {
+	if r.Type != "" {
+		if ok, _ := path.Match(r.Type, doc.resourceType); !ok {
+			return true
+		}
+	}
+
+	e := &env{doc: doc.value, vars: vars}
+
+	if r.when != nil && !r.when.holds(e) {
+		return true
+	}
+
+	return r.assert.holds(e)
+}
+
+// Guard enforces the rule set before the change set is executed, Template is the template being deployed
+type Guard struct {
+	RuleSet  *RuleSet
+	Template *packager.Template
+	Labels   []string
+}
+
+// Check returns report of the violated rules, nil is returned when there are none
+func (g *Guard) Check(changeSet *cloudformation.DescribeChangeSetOutput) error {
+	violations, err := g.RuleSet.Evaluate(g.Template, changeSet, g.Labels)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &Report{Violations: violations}
+}
+
+// document resource or resource change as generic JSON value the expressions are evaluated against
+type document struct {
+	logicalID    string
+	resourceType string
+	value        map[string]interface{}
+}
+
+// templateResources converts resources of the template into documents sorted by logical ID,
+// each with its LogicalId added
+func templateResources(template *packager.Template) ([]*document, error) {
+	docs := []*document{}
+
+	if template == nil {
+		return docs, nil
+	}
+
+	generic := struct {
+		Resources map[string]map[string]interface{}
+	}{}
+
+	if err := roundTrip(template, &generic); err != nil {
+		return nil, errors.Wrap(err, "error while converting template")
+	}
+
+	for logicalID, resource := range generic.Resources {
+		resource["LogicalId"] = logicalID
+		resourceType, _ := resource["Type"].(string)
+
+		docs = append(docs, &document{
+			logicalID:    logicalID,
+			resourceType: resourceType,
+			value:        resource,
+		})
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].logicalID < docs[j].logicalID
+	})
+
+	return docs, nil
+}
+
+// resourceChanges converts resource changes of the change set into documents in the change set order
+func resourceChanges(changeSet *cloudformation.DescribeChangeSetOutput) ([]*document, error) {
+	docs := []*document{}
+
+	if changeSet == nil {
+		return docs, nil
+	}
+
+	for _, c := range changeSet.Changes {
+		if c.ResourceChange == nil {
+			continue
+		}
+
+		value := map[string]interface{}{}
+		if err := roundTrip(c.ResourceChange, &value); err != nil {
+			return nil, errors.Wrap(err, "error while converting change set")
+		}
+
+		docs = append(docs, &document{
+			logicalID:    aws.StringValue(c.ResourceChange.LogicalResourceId),
+			resourceType: aws.StringValue(c.ResourceChange.ResourceType),
+			value:        value,
+		})
+	}
+
+	return docs, nil
+}
+
+func roundTrip(in interface{}, out interface{}) error {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, out)
+}
+
+func toInterfaces(values []string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+
+	return items
+}
@@ -0,0 +1,226 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/rules"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+var changeSet = &cloudformation.DescribeChangeSetOutput{
+	ChangeSetName: aws.String("cfn-1"),
+	Changes: []*cloudformation.Change{
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionModify),
+				LogicalResourceId: aws.String("Role"),
+				ResourceType:      aws.String("AWS::IAM::Role"),
+				Replacement:       aws.String(cloudformation.ReplacementFalse),
+				Scope:             aws.StringSlice([]string{"Properties"}),
+				Details: []*cloudformation.ResourceChangeDetail{
+					{
+						Target: &cloudformation.ResourceTargetDefinition{
+							Attribute: aws.String("Properties"),
+							Name:      aws.String("Policies"),
+						},
+					},
+				},
+			},
+		},
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(cloudformation.ChangeActionRemove),
+				LogicalResourceId: aws.String("Queue"),
+				ResourceType:      aws.String("AWS::SQS::Queue"),
+			},
+		},
+	},
+}
+
+func TestEvaluate(t *testing.T) {
+	ruleSet, err := rules.Load(afero.NewOsFs(), "testdata/rules.yml")
+	assert.NoError(t, err)
+
+	template, err := packager.New(logrus.New(), afero.NewOsFs()).Open("testdata/stack.yml")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		labels             []string
+		expectedViolations []*rules.Violation
+	}{
+		"evaluate reports violations of template and change set rules": {
+			expectedViolations: []*rules.Violation{
+				{
+					Rule:              "no-open-ingress",
+					Description:       "must not allow ingress from 0.0.0.0/0",
+					Target:            rules.TargetResource,
+					LogicalResourceId: "SecurityGroup",
+					ResourceType:      "AWS::EC2::SecurityGroup",
+				},
+				{
+					Rule:              "buckets-encrypted",
+					Description:       "must be encrypted",
+					Target:            rules.TargetResource,
+					LogicalResourceId: "PlainBucket",
+					ResourceType:      "AWS::S3::Bucket",
+				},
+				{
+					Rule:              "iam-changes-approved",
+					Description:       "must be approved with iam-approved label",
+					Target:            rules.TargetChange,
+					LogicalResourceId: "Role",
+					ResourceType:      "AWS::IAM::Role",
+				},
+			},
+		},
+		"evaluate takes labels into account": {
+			labels: []string{"hotfix", "iam-approved"},
+			expectedViolations: []*rules.Violation{
+				{
+					Rule:              "no-open-ingress",
+					Description:       "must not allow ingress from 0.0.0.0/0",
+					Target:            rules.TargetResource,
+					LogicalResourceId: "SecurityGroup",
+					ResourceType:      "AWS::EC2::SecurityGroup",
+				},
+				{
+					Rule:              "buckets-encrypted",
+					Description:       "must be encrypted",
+					Target:            rules.TargetResource,
+					LogicalResourceId: "PlainBucket",
+					ResourceType:      "AWS::S3::Bucket",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			violations, err := ruleSet.Evaluate(template, changeSet, test.labels)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedViolations, violations)
+		})
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	tests := map[string]struct {
+		assert   string
+		violated []string
+	}{
+		"equality compares strings": {
+			assert:   "Action == 'Modify'",
+			violated: []string{"Queue"},
+		},
+		"inequality of missing value holds": {
+			assert:   "Replacement != 'True'",
+			violated: []string{},
+		},
+		"wildcard must hold for every value": {
+			assert:   "Details[*].Target.Name != 'Policies'",
+			violated: []string{"Role"},
+		},
+		"wildcard without values holds": {
+			assert:   "Details[*].Target.Name == 'Policies'",
+			violated: []string{},
+		},
+		"index selects single value": {
+			assert:   "Scope[0] == 'Properties'",
+			violated: []string{"Queue"},
+		},
+		"regular expression matches": {
+			assert:   "ResourceType =~ '^AWS::IAM::'",
+			violated: []string{"Queue"},
+		},
+		"contains looks up list": {
+			assert:   "Scope contains 'Properties'",
+			violated: []string{"Queue"},
+		},
+		"exists checks for value": {
+			assert:   "exists(Replacement)",
+			violated: []string{"Queue"},
+		},
+		"boolean operators combine conditions": {
+			assert:   "!(Action == 'Remove' || Action == 'Modify') && true",
+			violated: []string{"Role", "Queue"},
+		},
+		"null matches missing value": {
+			assert:   "Replacement == null || Replacement == 'False'",
+			violated: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ruleSet, err := rules.Parse([]byte("Rules:\n  - Name: test\n    Target: Change\n    Assert: \"" + test.assert + "\"\n"))
+			assert.NoError(t, err)
+
+			violations, err := ruleSet.Evaluate(nil, changeSet, nil)
+			assert.NoError(t, err)
+
+			violated := []string{}
+			for _, v := range violations {
+				violated = append(violated, v.LogicalResourceId)
+			}
+
+			assert.Equal(t, test.violated, violated)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]struct {
+		rule        string
+		expectedErr string
+	}{
+		"rule must have a name": {
+			rule:        "Assert: exists(Type)",
+			expectedErr: "rule 1 has no name",
+		},
+		"rule must have an assertion": {
+			rule:        "Name: test",
+			expectedErr: "rule test has no assertion",
+		},
+		"rule must have a known target": {
+			rule:        "{Name: test, Target: Stack, Assert: exists(Type)}",
+			expectedErr: "rule test has unknown target Stack, expected Resource or Change",
+		},
+		"assertion must be complete": {
+			rule:        "{Name: test, Assert: Type ==}",
+			expectedErr: "rule test has invalid assertion: unexpected end of expression",
+		},
+		"assertion must not have unknown operators": {
+			rule:        "{Name: test, Assert: Type = 1}",
+			expectedErr: "rule test has invalid assertion: unknown operator \"=\" at position 5",
+		},
+		"pattern must be valid": {
+			rule:        "{Name: test, Assert: \"Type =~ '('\"}",
+			expectedErr: "rule test has invalid assertion: invalid pattern at position 5: error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := rules.Parse([]byte("Rules:\n  - " + test.rule + "\n"))
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ruleSet, err := rules.Parse([]byte("Rules:\n  - {Name: no-removal, Target: Change, Assert: \"Action != 'Remove'\"}\n"))
+	assert.NoError(t, err)
+
+	g := &rules.Guard{RuleSet: ruleSet}
+
+	err = g.Check(changeSet)
+	assert.EqualError(t, err, "rules are violated: no-removal: Queue (AWS::SQS::Queue)")
+
+	assert.NoError(t, g.Check(&cloudformation.DescribeChangeSetOutput{}))
+}
@@ -0,0 +1,67 @@
+package streamer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// EventFilter narrows down stack events to a single stack operation. Since is a server-side
+// timestamp, pagination stops as soon as events older than Since are reached. ResourceStatus
+// matches any status containing it, e.g. FAILED matches both CREATE_FAILED and UPDATE_FAILED
+type EventFilter struct {
+	ClientRequestToken *string
+	Since              time.Time
+	ResourceStatus     string
+	ResourceType       string
+	LogicalResourceId  string
+}
+
+// Matches checks if event satisfies the filter
+func (f *EventFilter) Matches(e *cloudformation.StackEvent) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.isOlder(e) {
+		return false
+	}
+
+	if f.ClientRequestToken != nil && aws.StringValue(e.ClientRequestToken) != *f.ClientRequestToken {
+		return false
+	}
+
+	if f.ResourceStatus != "" && !strings.Contains(aws.StringValue(e.ResourceStatus), f.ResourceStatus) {
+		return false
+	}
+
+	if f.ResourceType != "" && aws.StringValue(e.ResourceType) != f.ResourceType {
+		return false
+	}
+
+	if f.LogicalResourceId != "" && aws.StringValue(e.LogicalResourceId) != f.LogicalResourceId {
+		return false
+	}
+
+	return true
+}
+
+// OperationFilter narrows down events to the latest operation of the stack, which
+// starts when the stack was last updated or created
+func OperationFilter(stack *cloudformation.Stack) *EventFilter {
+	filter := &EventFilter{
+		Since: aws.TimeValue(stack.CreationTime),
+	}
+
+	if stack.LastUpdatedTime != nil {
+		filter.Since = *stack.LastUpdatedTime
+	}
+
+	return filter
+}
+
+func (f *EventFilter) isOlder(e *cloudformation.StackEvent) bool {
+	return f != nil && !f.Since.IsZero() && aws.TimeValue(e.Timestamp).Before(f.Since)
+}
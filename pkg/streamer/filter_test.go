@@ -0,0 +1,100 @@
+package streamer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/internal/streamertest"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFilterMatches(t *testing.T) {
+	event := &cloudformation.StackEvent{
+		EventId:            aws.String("test1"),
+		ClientRequestToken: aws.String("token"),
+		LogicalResourceId:  aws.String("Function"),
+		ResourceType:       aws.String("AWS::Lambda::Function"),
+		ResourceStatus:     aws.String(cloudformation.ResourceStatusUpdateFailed),
+		Timestamp:          aws.Time(streamertest.Timestamp),
+	}
+
+	tests := map[string]struct {
+		Filter  *streamer.EventFilter
+		Matches bool
+	}{
+		"Nil filter matches everything": {
+			Matches: true,
+		},
+		"Empty filter matches everything": {
+			Filter:  &streamer.EventFilter{},
+			Matches: true,
+		},
+		"Events older than since don't match": {
+			Filter:  &streamer.EventFilter{Since: streamertest.Timestamp.Add(time.Second)},
+			Matches: false,
+		},
+		"Events of another operation don't match": {
+			Filter:  &streamer.EventFilter{ClientRequestToken: aws.String("another")},
+			Matches: false,
+		},
+		"Status matches any status containing it": {
+			Filter:  &streamer.EventFilter{ResourceStatus: "FAILED"},
+			Matches: true,
+		},
+		"Status doesn't match other statuses": {
+			Filter:  &streamer.EventFilter{ResourceStatus: "COMPLETE"},
+			Matches: false,
+		},
+		"All criteria must match": {
+			Filter: &streamer.EventFilter{
+				Since:             streamertest.Timestamp,
+				ResourceType:      "AWS::Lambda::Function",
+				LogicalResourceId: "Bucket",
+			},
+			Matches: false,
+		},
+		"Resource type and logical ID match exactly": {
+			Filter: &streamer.EventFilter{
+				ResourceType:      "AWS::Lambda::Function",
+				LogicalResourceId: "Function",
+			},
+			Matches: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Matches, test.Filter.Matches(event))
+		})
+	}
+}
+
+func TestOperationFilter(t *testing.T) {
+	tests := map[string]struct {
+		Stack *cloudformation.Stack
+		Since time.Time
+	}{
+		"Operation of a new stack starts at its creation": {
+			Stack: &cloudformation.Stack{
+				CreationTime: aws.Time(streamertest.Timestamp),
+			},
+			Since: streamertest.Timestamp,
+		},
+		"Operation of an updated stack starts at its last update": {
+			Stack: &cloudformation.Stack{
+				CreationTime:    aws.Time(streamertest.Timestamp),
+				LastUpdatedTime: aws.Time(streamertest.Timestamp.Add(time.Hour)),
+			},
+			Since: streamertest.Timestamp.Add(time.Hour),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &streamer.EventFilter{Since: test.Since}, streamer.OperationFilter(test.Stack))
+		})
+	}
+}
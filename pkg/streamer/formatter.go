@@ -0,0 +1,62 @@
+package streamer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+// TextFormatter returns a formatter that writes each stack event as a single line
+// with timestamp, logical ID, resource type, status and reason, status is colour-coded when colored is true.
+// Logical IDs of nested stack events are prefixed with the nested stack path
+func TextFormatter(colored bool) writer.FormatFunc {
+	return func(wr io.Writer, message interface{}) {
+		var e *cloudformation.StackEvent
+		var logicalID string
+
+		switch m := message.(type) {
+		case *cloudformation.StackEvent:
+			e = m
+			logicalID = aws.StringValue(e.LogicalResourceId)
+		case *NestedStackEvent:
+			e = m.StackEvent
+			logicalID = m.Path + "/" + aws.StringValue(e.LogicalResourceId)
+		default:
+			writer.PlainFormatter(wr, message)
+			return
+		}
+
+		status := fmt.Sprintf("%-45s", aws.StringValue(e.ResourceStatus))
+		if colored {
+			status = colorize(aws.StringValue(e.ResourceStatus), status)
+		}
+
+		line := fmt.Sprintf("%s  %-30s  %-40s  %s  %s",
+			aws.TimeValue(e.Timestamp).UTC().Format(time.RFC3339),
+			logicalID,
+			aws.StringValue(e.ResourceType),
+			status,
+			aws.StringValue(e.ResourceStatusReason),
+		)
+
+		fmt.Fprintln(wr, strings.TrimRight(line, " "))
+	}
+}
+
+func colorize(status string, text string) string {
+	color := writer.ColorYellow
+
+	switch {
+	case strings.HasSuffix(status, "_FAILED"), strings.HasPrefix(status, "ROLLBACK_"), strings.Contains(status, "_ROLLBACK_"):
+		color = writer.ColorRed
+	case strings.HasSuffix(status, "_COMPLETE"):
+		color = writer.ColorGreen
+	}
+
+	return writer.Colorize(text, color)
+}
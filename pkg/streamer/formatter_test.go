@@ -0,0 +1,59 @@
+package streamer_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextFormatter(t *testing.T) {
+	event := &cloudformation.StackEvent{
+		EventId:              aws.String("test1"),
+		Timestamp:            aws.Time(time.Date(2018, 3, 25, 7, 12, 57, 0, time.UTC)),
+		LogicalResourceId:    aws.String("S3Bucket"),
+		ResourceType:         aws.String("AWS::S3::Bucket"),
+		ResourceStatus:       aws.String(cloudformation.ResourceStatusCreateFailed),
+		ResourceStatusReason: aws.String("helloza already exists"),
+	}
+
+	tests := map[string]struct {
+		colored  bool
+		message  interface{}
+		expected string
+	}{
+		"event is written as a single line": {
+			message:  event,
+			expected: "2018-03-25T07:12:57Z  S3Bucket                        AWS::S3::Bucket                           CREATE_FAILED                                  helloza already exists\n",
+		},
+		"event status is colour-coded": {
+			colored:  true,
+			message:  event,
+			expected: "2018-03-25T07:12:57Z  S3Bucket                        AWS::S3::Bucket                           \x1b[31mCREATE_FAILED                                \x1b[0m  helloza already exists\n",
+		},
+		"nested stack event is prefixed with its path": {
+			message: &streamer.NestedStackEvent{
+				Path:       "Network/Subnets",
+				StackEvent: event,
+			},
+			expected: "2018-03-25T07:12:57Z  Network/Subnets/S3Bucket        AWS::S3::Bucket                           CREATE_FAILED                                  helloza already exists\n",
+		},
+		"other messages are written as is": {
+			message:  "hello world",
+			expected: "hello world\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			streamer.TextFormatter(test.colored)(out, test.message)
+
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
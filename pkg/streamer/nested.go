@@ -0,0 +1,119 @@
+package streamer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// NestedStackEvent stack event of a nested stack, Path is a chain of logical IDs from the root stack
+type NestedStackEvent struct {
+	Path string
+	*cloudformation.StackEvent
+}
+
+type nestedStack struct {
+	path    string
+	stackID *string
+	since   time.Time
+}
+
+type nestedEventsRecord struct {
+	*nestedStack
+	StackEventsRecord
+	isFinished bool
+}
+
+// discoverNestedStack starts polling a nested stack when event reports it's being created, updated or deleted
+func (s *Streamer) discoverNestedStack(e *cloudformation.StackEvent, parentPath string, nestedStacks map[string]*nestedStack, out chan<- *nestedEventsRecord, stop <-chan struct{}, abort <-chan struct{}) {
+	if !s.isNestedStackEvent(e) || !strings.HasSuffix(aws.StringValue(e.ResourceStatus), "_IN_PROGRESS") {
+		return
+	}
+
+	if _, exists := nestedStacks[*e.PhysicalResourceId]; exists {
+		return
+	}
+
+	path := *e.LogicalResourceId
+	if parentPath != "" {
+		path = parentPath + "/" + path
+	}
+
+	nested := &nestedStack{
+		path:    path,
+		stackID: e.PhysicalResourceId,
+		since:   aws.TimeValue(e.Timestamp),
+	}
+
+	nestedStacks[*e.PhysicalResourceId] = nested
+
+	s.logger.WithField("nestedStack", path).Debug("Nested stack has been discovered, start streaming its events")
+
+	go s.pollNestedStack(nested, out, stop, abort)
+}
+
+func (s *Streamer) pollNestedStack(nested *nestedStack, out chan<- *nestedEventsRecord, stop <-chan struct{}, abort <-chan struct{}) {
+	seenEvents := StackEvents{}
+	filter := &EventFilter{Since: nested.since}
+	isLastPoll := false
+
+	interval := s.pollInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		r := s.DescribeStackEvents(nested.stackID, filter, seenEvents)
+
+		record := &nestedEventsRecord{
+			nestedStack: nested,
+			isFinished:  r.Err != nil || isLastPoll,
+		}
+		record.Err = r.Err
+
+		for _, e := range r.Records {
+			seenEvents[*e.EventId] = e
+			record.Records = append(record.Records, e)
+
+			if s.isNestedStackFinished(e, nested.stackID) {
+				record.isFinished = true
+			}
+		}
+
+		select {
+		case out <- record:
+		case <-abort:
+			return
+		}
+
+		if record.isFinished {
+			return
+		}
+
+		interval = s.nextPollInterval(interval, len(record.Records) != 0)
+		s.resetTimer(timer, interval)
+
+		select {
+		case <-timer.C:
+		case <-stop:
+			isLastPoll = true
+			stop = nil
+		case <-abort:
+			return
+		}
+	}
+}
+
+func (s *Streamer) isNestedStackEvent(e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" &&
+		aws.StringValue(e.PhysicalResourceId) != "" &&
+		aws.StringValue(e.PhysicalResourceId) != aws.StringValue(e.StackId)
+}
+
+// isNestedStackFinished checks if event reports the nested stack itself reaching a terminal status
+func (s *Streamer) isNestedStackFinished(e *cloudformation.StackEvent, stackID *string) bool {
+	return aws.StringValue(e.PhysicalResourceId) == *stackID &&
+		aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" &&
+		!strings.HasSuffix(aws.StringValue(e.ResourceStatus), "_IN_PROGRESS")
+}
@@ -0,0 +1,70 @@
+package streamer
+
+import (
+	"os"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/spf13/afero"
+)
+
+// EventSink receives streamed stack events, events of the stack itself are given as
+// *cloudformation.StackEvent and events of its nested stacks as *NestedStackEvent.
+// Write is called from a single goroutine, a slow sink delays the streaming
+type EventSink interface {
+	Write(event interface{})
+}
+
+// FuncSink calls the function with each stack event
+type FuncSink func(event interface{})
+
+// Write calls the function with event
+func (f FuncSink) Write(event interface{}) {
+	f(event)
+}
+
+// ChannelSink sends each stack event to the channel, sending blocks until the event is received
+type ChannelSink chan<- interface{}
+
+// Write sends event to the channel
+func (c ChannelSink) Write(event interface{}) {
+	c <- event
+}
+
+// MultiSink writes each stack event to all of its sinks in order
+type MultiSink []EventSink
+
+// Write writes event to all sinks
+func (m MultiSink) Write(event interface{}) {
+	for _, sink := range m {
+		sink.Write(event)
+	}
+}
+
+// FileSink writes stack events into a file as newline delimited JSON
+type FileSink struct {
+	file afero.File
+	wr   *writer.StringWriter
+}
+
+// NewFileSink creates the file or truncates it if it already exists
+func NewFileSink(fs afero.Fs, filename string) (*FileSink, error) {
+	file, err := fs.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		file: file,
+		wr:   writer.New(file, writer.NDJSONFormatter),
+	}, nil
+}
+
+// Write appends event to the file
+func (f *FileSink) Write(event interface{}) {
+	f.wr.Write(event)
+}
+
+// Close closes the underlying file
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,74 @@
+package streamer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncSink(t *testing.T) {
+	event := &cloudformation.StackEvent{EventId: aws.String("test1")}
+	received := []interface{}{}
+
+	sink := streamer.FuncSink(func(e interface{}) {
+		received = append(received, e)
+	})
+	sink.Write(event)
+
+	assert.Equal(t, []interface{}{event}, received)
+}
+
+func TestChannelSink(t *testing.T) {
+	event := &cloudformation.StackEvent{EventId: aws.String("test1")}
+	ch := make(chan interface{}, 1)
+
+	streamer.ChannelSink(ch).Write(event)
+
+	assert.Equal(t, event, <-ch)
+}
+
+func TestMultiSink(t *testing.T) {
+	event := &streamer.NestedStackEvent{
+		Path:       "Network",
+		StackEvent: &cloudformation.StackEvent{EventId: aws.String("test1")},
+	}
+	first := make(chan interface{}, 1)
+	second := make(chan interface{}, 1)
+
+	streamer.MultiSink{streamer.ChannelSink(first), streamer.ChannelSink(second)}.Write(event)
+
+	assert.Equal(t, event, <-first)
+	assert.Equal(t, event, <-second)
+}
+
+func TestFileSink(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "events.json", []byte("previous run\n"), 0644)
+
+	sink, err := streamer.NewFileSink(fs, "events.json")
+	assert.NoError(t, err)
+
+	events := []interface{}{
+		&cloudformation.StackEvent{EventId: aws.String("test1")},
+		&streamer.NestedStackEvent{
+			Path:       "Network",
+			StackEvent: &cloudformation.StackEvent{EventId: aws.String("test2")},
+		},
+	}
+
+	expected := ""
+	for _, e := range events {
+		sink.Write(e)
+		raw, _ := json.Marshal(e)
+		expected += string(raw) + "\n"
+	}
+	assert.NoError(t, sink.Close())
+
+	raw, _ := afero.ReadFile(fs, "events.json")
+	assert.Equal(t, expected, string(raw))
+}
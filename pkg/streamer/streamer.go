@@ -2,97 +2,247 @@ package streamer
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
-	"github.com/b-b3rn4rd/gocfn/pkg/writer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// StackEvents stack events indexed by EventId
 type StackEvents map[string]*cloudformation.StackEvent
 
+// StackEventList stack events in chronological order
+type StackEventList []*cloudformation.StackEvent
+
+// ByID indexes events by EventId
+func (l StackEventList) ByID() StackEvents {
+	events := make(StackEvents, len(l))
+
+	for _, e := range l {
+		events[*e.EventId] = e
+	}
+
+	return events
+}
+
 type Streamer struct {
-	svc    cloudformationiface.CloudFormationAPI
-	logger *logrus.Logger
+	svc             cloudformationiface.CloudFormationAPI
+	logger          *logrus.Logger
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	pollBackoff     float64
 }
 
 type StackEventsRecord struct {
-	Records StackEvents
+	Records StackEventList
 	Err     error
 }
 
 type Streameriface interface {
-	StartStreaming(*string, StackEvents, *writer.StringWriter, <-chan bool) error
-	DescribeStackEvents(*string, StackEvents) (stackEvents *StackEventsRecord)
+	StartStreaming(*string, *EventFilter, EventSink, <-chan bool) error
+	DescribeStackEvents(*string, *EventFilter, StackEvents) (stackEvents *StackEventsRecord)
+}
+
+// PollInterval sets the interval between polls while new events keep coming
+func PollInterval(pollInterval time.Duration) func(stmr *Streamer) {
+	return func(stmr *Streamer) {
+		stmr.pollInterval = pollInterval
+	}
 }
 
-func New(svc cloudformationiface.CloudFormationAPI, logger *logrus.Logger) *Streamer {
-	return &Streamer{
-		svc:    svc,
-		logger: logger,
+// MaxPollInterval sets the maximum interval between polls
+func MaxPollInterval(maxPollInterval time.Duration) func(stmr *Streamer) {
+	return func(stmr *Streamer) {
+		stmr.maxPollInterval = maxPollInterval
 	}
 }
 
-func (s *Streamer) StartStreaming(stackName *string, seenEvents StackEvents, wr *writer.StringWriter, done <-chan bool) error {
+// PollBackoff sets the factor the interval is multiplied by after each poll without new events
+func PollBackoff(pollBackoff float64) func(stmr *Streamer) {
+	return func(stmr *Streamer) {
+		stmr.pollBackoff = pollBackoff
+	}
+}
+
+// ValidatePolling checks polling settings, zero interval or backoff below 1 would poll in a tight loop
+func ValidatePolling(pollInterval time.Duration, maxPollInterval time.Duration, pollBackoff float64) error {
+	switch {
+	case pollInterval <= 0:
+		return fmt.Errorf("poll interval must be positive, got %s", pollInterval)
+	case maxPollInterval < pollInterval:
+		return fmt.Errorf("max poll interval %s must not be less than poll interval %s", maxPollInterval, pollInterval)
+	case pollBackoff < 1:
+		return fmt.Errorf("poll backoff must be at least 1, got %g", pollBackoff)
+	}
+
+	return nil
+}
+
+func New(svc cloudformationiface.CloudFormationAPI, logger *logrus.Logger, options ...func(stmr *Streamer)) *Streamer {
+	stmr := &Streamer{
+		svc:             svc,
+		logger:          logger,
+		pollInterval:    time.Second * 2,
+		maxPollInterval: time.Second * 15,
+		pollBackoff:     2,
+	}
+
+	for _, option := range options {
+		option(stmr)
+	}
+
+	return stmr
+}
+
+// StartStreaming writes stack events matching filter into sink until done is closed and the last poll has finished
+func (s *Streamer) StartStreaming(stackName *string, filter *EventFilter, sink EventSink, done <-chan bool) error {
 	s.logger.WithField("stackName", *stackName).Debug("Start streaming stack events")
-	s.logger.WithField("stackName", *stackName).Debug(fmt.Sprintf("Stack has %d existing events", len(seenEvents)))
+
+	seenEvents := StackEvents{}
 
 	ch := make(chan *StackEventsRecord, 1)
+	nestedCh := make(chan *nestedEventsRecord)
+
+	// stop asks nested pollers to do their last poll, abort terminates them straight away
+	stop := make(chan struct{})
+	abort := make(chan struct{})
+	defer close(abort)
+
+	nestedStacks := map[string]*nestedStack{}
+
+	interval := s.pollInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
-	ticker := time.NewTicker(time.Second * 1)
 	isStackReady := false
 	isLastPoll := false
+	isPolling := false
+	isFinished := false
 
 	for {
 		select {
 		case <-done:
+			// done is closed by the waiter, stop selecting on it once received
+			done = nil
 			isStackReady = true
 			s.logger.WithField("stackName", *stackName).Debug("Stack creation/update has finished")
+
+			if !isPolling {
+				// don't wait for the idle interval to run out
+				s.resetTimer(timer, 0)
+			}
 		case r := <-ch:
+			isPolling = false
+
 			if r.Err != nil {
 				return errors.Wrap(r.Err, "AWS error while running DescribeStackEvents")
 			}
 
 			for _, e := range r.Records {
-				wr.Write(e)
+				sink.Write(e)
 				seenEvents[*e.EventId] = e
+				s.discoverNestedStack(e, "", nestedStacks, nestedCh, stop, abort)
 			}
 
 			if isLastPoll {
 				s.logger.WithField("stackName", *stackName).Debug("Stack is ready and the last poll has finished")
-				return nil
+
+				if len(nestedStacks) == 0 {
+					return nil
+				}
+
+				s.logger.WithField("stackName", *stackName).Debug(fmt.Sprintf("Waiting for %d nested stacks to finish streaming", len(nestedStacks)))
+				isFinished = true
+				close(stop)
 			}
 
-			if isStackReady {
+			interval = s.nextPollInterval(interval, len(r.Records) != 0)
+
+			if isStackReady && !isLastPoll {
 				isLastPoll = true
+				interval = s.pollInterval
 				s.logger.WithField("stackName", *stackName).Debug("Stack is ready, doing the last poll")
 			}
 
-		case <-ticker.C:
-			s.logger.WithField("stackName", *stackName).Debug("Polling for new stack events")
+			if !isFinished {
+				timer.Reset(interval)
+			}
+
+		case r := <-nestedCh:
+			if r.Err != nil {
+				return errors.Wrap(r.Err, fmt.Sprintf("AWS error while running DescribeStackEvents for nested stack %s", r.path))
+			}
+
+			for _, e := range r.Records {
+				sink.Write(&NestedStackEvent{Path: r.path, StackEvent: e})
+				s.discoverNestedStack(e, r.path, nestedStacks, nestedCh, stop, abort)
+			}
+
+			if r.isFinished {
+				s.logger.WithField("stackName", *stackName).WithField("nestedStack", r.path).Debug("Nested stack has finished")
+				delete(nestedStacks, *r.stackID)
+			}
+
+			if isFinished && len(nestedStacks) == 0 {
+				s.logger.WithField("stackName", *stackName).Debug("All nested stacks have finished streaming")
+				return nil
+			}
+
+		case <-timer.C:
+			isPolling = true
+			s.logger.WithField("stackName", *stackName).WithField("interval", interval.String()).Debug("Polling for new stack events")
 			go func() {
-				ch <- s.DescribeStackEvents(stackName, seenEvents)
+				ch <- s.DescribeStackEvents(stackName, filter, seenEvents)
 			}()
-			ticker = time.NewTicker(time.Second * 15)
 		}
 	}
 }
 
-func (s *Streamer) DescribeStackEvents(stackName *string, seenEvents StackEvents) (stackEvents *StackEventsRecord) {
-	stackEvents = &StackEventsRecord{Records: StackEvents{}}
+// nextPollInterval keeps polling fast while events are flowing and backs off when stack is idle
+func (s *Streamer) nextPollInterval(interval time.Duration, hasEvents bool) time.Duration {
+	if hasEvents {
+		return s.pollInterval
+	}
+
+	interval = time.Duration(float64(interval) * s.pollBackoff)
+	if interval > s.maxPollInterval {
+		interval = s.maxPollInterval
+	}
+
+	return interval
+}
+
+// resetTimer resets the timer which may have fired but not been received
+func (s *Streamer) resetTimer(timer *time.Timer, interval time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+
+	timer.Reset(interval)
+}
+
+// DescribeStackEvents returns events matching filter which are not in seenEvents, ordered by Timestamp
+func (s *Streamer) DescribeStackEvents(stackName *string, filter *EventFilter, seenEvents StackEvents) (stackEvents *StackEventsRecord) {
+	stackEvents = &StackEventsRecord{Records: StackEventList{}}
 
 	err := s.svc.DescribeStackEventsPages(&cloudformation.DescribeStackEventsInput{
 		StackName: stackName,
 	}, func(page *cloudformation.DescribeStackEventsOutput, isLastPage bool) bool {
 		for _, stackEvent := range page.StackEvents {
-			if len(seenEvents) == 0 {
-				stackEvents.Records[*stackEvent.EventId] = stackEvent
-			} else {
-				if _, exists := seenEvents[*stackEvent.EventId]; !exists {
-					stackEvents.Records[*stackEvent.EventId] = stackEvent
-				}
+			if filter.isOlder(stackEvent) {
+				s.logger.WithField("stackName", *stackName).Debug("Reached events older than the operation, stop paginating")
+				return false
+			}
+
+			if _, exists := seenEvents[*stackEvent.EventId]; !exists && filter.Matches(stackEvent) {
+				stackEvents.Records = append(stackEvents.Records, stackEvent)
 			}
 		}
 		return !isLastPage
@@ -103,5 +253,15 @@ func (s *Streamer) DescribeStackEvents(stackName *string, seenEvents StackEvents
 		return
 	}
 
+	// events are returned in reverse chronological order, reversing them first
+	// keeps the original order of events with identical timestamps
+	for i, j := 0, len(stackEvents.Records)-1; i < j; i, j = i+1, j-1 {
+		stackEvents.Records[i], stackEvents.Records[j] = stackEvents.Records[j], stackEvents.Records[i]
+	}
+
+	sort.SliceStable(stackEvents.Records, func(i, j int) bool {
+		return aws.TimeValue(stackEvents.Records[i].Timestamp).Before(aws.TimeValue(stackEvents.Records[j].Timestamp))
+	})
+
 	return
 }
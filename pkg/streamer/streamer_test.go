@@ -3,11 +3,16 @@ package streamer_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/b-b3rn4rd/gocfn/internal/streamertest"
 	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
 	"github.com/b-b3rn4rd/gocfn/pkg/writer"
 	"github.com/pkg/errors"
@@ -16,20 +21,45 @@ import (
 )
 
 type mockedCloudFormationAPI struct {
-	Resp error
+	Resp   error
+	Events map[string][]*cloudformation.StackEvent
+	Calls  *int64
 	cloudformationiface.CloudFormationAPI
 }
 
 func (m mockedCloudFormationAPI) DescribeStackEventsPages(input *cloudformation.DescribeStackEventsInput, fn func(*cloudformation.DescribeStackEventsOutput, bool) bool) error {
+	if m.Calls != nil {
+		atomic.AddInt64(m.Calls, 1)
+	}
+
+	if m.Events != nil {
+		fn(&cloudformation.DescribeStackEventsOutput{
+			StackEvents: m.Events[*input.StackName],
+		}, true)
+		return m.Resp
+	}
+
+	fn(&cloudformation.DescribeStackEventsOutput{
+		StackEvents: []*cloudformation.StackEvent{
+			{
+				EventId:   aws.String("test4"),
+				Timestamp: aws.Time(streamertest.Timestamp.Add(time.Second)),
+			},
+			{
+				EventId:   aws.String("test3"),
+				Timestamp: aws.Time(streamertest.Timestamp.Add(time.Second)),
+			},
+		},
+	}, false)
 	fn(&cloudformation.DescribeStackEventsOutput{
 		StackEvents: []*cloudformation.StackEvent{
 			{
-				EventId: aws.String("test1"),
+				EventId:   aws.String("test1"),
+				Timestamp: aws.Time(streamertest.Timestamp),
 			},
 			{
-				EventId: aws.String("test2"),
-			}, {
-				EventId: aws.String("test3"),
+				EventId:   aws.String("test2"),
+				Timestamp: aws.Time(streamertest.Timestamp.Add(time.Millisecond)),
 			},
 		},
 	}, true)
@@ -42,23 +72,14 @@ func TestDescribeStackEvents(t *testing.T) {
 	tests := map[string]struct {
 		Svc        cloudformationiface.CloudFormationAPI
 		Err        error
+		Filter     *streamer.EventFilter
 		SeenEvents streamer.StackEvents
-		Records    streamer.StackEvents
+		Records    []string
 	}{
-		"Describe stack events return all events if seen is empty": {
-			Svc: mockedCloudFormationAPI{},
-			Err: nil,
-			Records: streamer.StackEvents{
-				"test1": &cloudformation.StackEvent{
-					EventId: aws.String("test1"),
-				},
-				"test2": &cloudformation.StackEvent{
-					EventId: aws.String("test2"),
-				},
-				"test3": &cloudformation.StackEvent{
-					EventId: aws.String("test3"),
-				},
-			},
+		"Describe stack events return all events in chronological order if seen is empty": {
+			Svc:     mockedCloudFormationAPI{},
+			Err:     nil,
+			Records: []string{"test1", "test2", "test3", "test4"},
 		},
 		"Describe stack events return only unseen events": {
 			Svc: mockedCloudFormationAPI{},
@@ -68,14 +89,40 @@ func TestDescribeStackEvents(t *testing.T) {
 					EventId: aws.String("test2"),
 				},
 			},
-			Records: streamer.StackEvents{
-				"test1": &cloudformation.StackEvent{
-					EventId: aws.String("test1"),
-				},
-				"test3": &cloudformation.StackEvent{
-					EventId: aws.String("test3"),
+			Records: []string{"test1", "test3", "test4"},
+		},
+		"Describe stack events stops at events older than the filter": {
+			Svc: mockedCloudFormationAPI{},
+			Filter: &streamer.EventFilter{
+				Since: streamertest.Timestamp.Add(time.Millisecond),
+			},
+			Records: []string{"test3", "test4"},
+		},
+		"Describe stack events return only events of the operation": {
+			Svc: mockedCloudFormationAPI{
+				Events: map[string][]*cloudformation.StackEvent{
+					"test": {
+						{
+							EventId:            aws.String("test3"),
+							ClientRequestToken: aws.String("current"),
+							Timestamp:          aws.Time(streamertest.Timestamp.Add(time.Second)),
+						},
+						{
+							EventId:   aws.String("test2"),
+							Timestamp: aws.Time(streamertest.Timestamp.Add(time.Second)),
+						},
+						{
+							EventId:            aws.String("test1"),
+							ClientRequestToken: aws.String("previous"),
+							Timestamp:          aws.Time(streamertest.Timestamp),
+						},
+					},
 				},
 			},
+			Filter: &streamer.EventFilter{
+				ClientRequestToken: aws.String("current"),
+			},
+			Records: []string{"test3"},
 		},
 	}
 
@@ -83,8 +130,83 @@ func TestDescribeStackEvents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 
 			s := streamer.New(test.Svc, logrus.New())
-			res := s.DescribeStackEvents(stackName, test.SeenEvents)
-			assert.Equal(t, test.Records, res.Records)
+			res := s.DescribeStackEvents(stackName, test.Filter, test.SeenEvents)
+
+			actual := []string{}
+			for _, e := range res.Records {
+				actual = append(actual, *e.EventId)
+			}
+
+			assert.Equal(t, test.Records, actual)
+		})
+	}
+}
+
+func TestStackEventListByID(t *testing.T) {
+	list := streamer.StackEventList{
+		{EventId: aws.String("test1")},
+		{EventId: aws.String("test2")},
+	}
+
+	assert.Equal(t, streamer.StackEvents{
+		"test1": list[0],
+		"test2": list[1],
+	}, list.ByID())
+}
+
+func TestValidatePolling(t *testing.T) {
+	tests := map[string]struct {
+		pollInterval    time.Duration
+		maxPollInterval time.Duration
+		pollBackoff     float64
+		err             error
+	}{
+		"default settings are valid": {
+			pollInterval:    time.Second * 2,
+			maxPollInterval: time.Second * 15,
+			pollBackoff:     2,
+		},
+		"backoff of 1 keeps the same interval": {
+			pollInterval:    time.Second,
+			maxPollInterval: time.Second,
+			pollBackoff:     1,
+		},
+		"zero interval is rejected": {
+			pollInterval:    0,
+			maxPollInterval: time.Second * 15,
+			pollBackoff:     2,
+			err:             errors.New("poll interval must be positive, got 0s"),
+		},
+		"negative interval is rejected": {
+			pollInterval:    -time.Second,
+			maxPollInterval: time.Second * 15,
+			pollBackoff:     2,
+			err:             errors.New("poll interval must be positive, got -1s"),
+		},
+		"max interval below interval is rejected": {
+			pollInterval:    time.Second * 2,
+			maxPollInterval: time.Second,
+			pollBackoff:     2,
+			err:             errors.New("max poll interval 1s must not be less than poll interval 2s"),
+		},
+		"backoff below 1 is rejected": {
+			pollInterval:    time.Second * 2,
+			maxPollInterval: time.Second * 15,
+			pollBackoff:     0.5,
+			err:             errors.New("poll backoff must be at least 1, got 0.5"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := streamer.ValidatePolling(test.pollInterval, test.maxPollInterval, test.pollBackoff)
+
+			if test.err != nil {
+				assert.EqualError(t, err, test.err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
 		})
 	}
 }
@@ -95,24 +217,24 @@ func TestStartStreaming(t *testing.T) {
 	sw := writer.New(wr, writer.JSONFormatter)
 	done := make(chan bool, 1)
 	tests := map[string]struct {
-		Svc        cloudformationiface.CloudFormationAPI
-		SeenEvents streamer.StackEvents
-		Records    []*cloudformation.StackEvent
-		Err        error
+		Svc     cloudformationiface.CloudFormationAPI
+		Filter  *streamer.EventFilter
+		Records []*cloudformation.StackEvent
+		Err     error
 	}{
-		"All unseen events are streamed": {
+		"Events of the operation are streamed": {
 			Svc: mockedCloudFormationAPI{},
-			SeenEvents: streamer.StackEvents{
-				"test2": &cloudformation.StackEvent{
-					EventId: aws.String("test2"),
-				},
+			Filter: &streamer.EventFilter{
+				Since: streamertest.Timestamp.Add(time.Millisecond),
 			},
 			Records: []*cloudformation.StackEvent{
 				{
-					EventId: aws.String("test1"),
+					EventId:   aws.String("test3"),
+					Timestamp: aws.Time(streamertest.Timestamp.Add(time.Second)),
 				},
 				{
-					EventId: aws.String("test3"),
+					EventId:   aws.String("test4"),
+					Timestamp: aws.Time(streamertest.Timestamp.Add(time.Second)),
 				},
 			},
 		},
@@ -127,9 +249,9 @@ func TestStartStreaming(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 
-			s := streamer.New(test.Svc, logrus.New())
+			s := streamer.New(test.Svc, logrus.New(), streamer.PollInterval(time.Millisecond))
 			done <- true
-			err := s.StartStreaming(stackName, test.SeenEvents, sw, done)
+			err := s.StartStreaming(stackName, test.Filter, sw, done)
 
 			if err != nil {
 				assert.Error(t, test.Err, err)
@@ -146,3 +268,104 @@ func TestStartStreaming(t *testing.T) {
 		})
 	}
 }
+
+func TestStartStreamingNestedStacks(t *testing.T) {
+	// events are returned in reverse chronological order
+	svc := mockedCloudFormationAPI{
+		Events: map[string][]*cloudformation.StackEvent{
+			"test": {
+				streamertest.StackEvent("root", "Network", "child", "AWS::CloudFormation::Stack", "CREATE_FAILED", "", time.Second*5),
+				streamertest.StackEvent("root", "Network", "child", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("root", "Network", "", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "", time.Second),
+			},
+			"child": {
+				streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "CREATE_FAILED", "", time.Second*4),
+				streamertest.StackEvent("child", "Subnets", "grandchild", "AWS::CloudFormation::Stack", "CREATE_FAILED", "", time.Second*4),
+				streamertest.StackEvent("child", "Subnets", "grandchild", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "", time.Second*2),
+				streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("child", "test-Network", "child", "AWS::CloudFormation::Stack", "DELETE_COMPLETE", "", -time.Second*10),
+			},
+			"grandchild": {
+				streamertest.StackEvent("grandchild", "test-Network-Subnets", "grandchild", "AWS::CloudFormation::Stack", "CREATE_FAILED", "", time.Second*3),
+				streamertest.StackEvent("grandchild", "Subnet", "", "AWS::EC2::Subnet", "CREATE_FAILED", "", time.Second*3),
+				streamertest.StackEvent("grandchild", "test-Network-Subnets", "grandchild", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "", time.Second*2),
+			},
+		},
+	}
+
+	lines := []string{}
+	sw := writer.New(&bytes.Buffer{}, func(wr io.Writer, message interface{}) {
+		switch e := message.(type) {
+		case *cloudformation.StackEvent:
+			lines = append(lines, fmt.Sprintf("%s %s", *e.LogicalResourceId, *e.ResourceStatus))
+		case *streamer.NestedStackEvent:
+			lines = append(lines, fmt.Sprintf("%s/%s %s", e.Path, *e.LogicalResourceId, *e.ResourceStatus))
+		}
+	})
+
+	done := make(chan bool)
+	close(done)
+
+	s := streamer.New(svc, logrus.New(), streamer.PollInterval(time.Millisecond))
+	err := s.StartStreaming(aws.String("test"), nil, sw, done)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"Network CREATE_IN_PROGRESS",
+		"Network CREATE_IN_PROGRESS",
+		"Network CREATE_FAILED",
+		"Network/test-Network CREATE_IN_PROGRESS",
+		"Network/Subnets CREATE_IN_PROGRESS",
+		"Network/Subnets CREATE_FAILED",
+		"Network/test-Network CREATE_FAILED",
+		"Network/Subnets/test-Network-Subnets CREATE_IN_PROGRESS",
+		"Network/Subnets/Subnet CREATE_FAILED",
+		"Network/Subnets/test-Network-Subnets CREATE_FAILED",
+	}, lines)
+}
+
+func TestStartStreamingBacksOffWhenIdle(t *testing.T) {
+	tests := map[string]struct {
+		backoff  float64
+		minPolls int64
+		maxPolls int64
+	}{
+		"polls keep the same interval without backoff": {
+			backoff:  1,
+			minPolls: 10,
+			maxPolls: 22,
+		},
+		"polls back off while there are no new events": {
+			backoff:  2,
+			minPolls: 3,
+			maxPolls: 8,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := int64(0)
+			svc := mockedCloudFormationAPI{
+				Events: map[string][]*cloudformation.StackEvent{},
+				Calls:  &calls,
+			}
+
+			s := streamer.New(svc, logrus.New(),
+				streamer.PollInterval(10*time.Millisecond),
+				streamer.MaxPollInterval(80*time.Millisecond),
+				streamer.PollBackoff(test.backoff),
+			)
+
+			done := make(chan bool)
+			time.AfterFunc(200*time.Millisecond, func() {
+				close(done)
+			})
+
+			err := s.StartStreaming(aws.String("test"), nil, writer.New(&bytes.Buffer{}, writer.JSONFormatter), done)
+
+			assert.NoError(t, err)
+			assert.True(t, atomic.LoadInt64(&calls) >= test.minPolls, "expected at least %d polls, got %d", test.minPolls, calls)
+			assert.True(t, atomic.LoadInt64(&calls) <= test.maxPolls, "expected at most %d polls, got %d", test.maxPolls, calls)
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package templatediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// number of unchanged lines written around changed lines
+const contextLines = 3
+
+// Sections of the template compared by Diff in the order changes are reported
+var Sections = []string{"Parameters", "Resources", "Outputs"}
+
+// Change difference of a single parameter, resource or output between the deployed template and the template
+type Change struct {
+	Section   string
+	LogicalId string
+	Action    string
+	Hunks     []*Hunk
+}
+
+// Hunk changed lines of the parameter, resource or output rendered as YAML together with unchanged lines
+// around them, lines are prefixed with space, - or + and line numbers start at 1 as in unified diff
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string
+}
+
+// Diff compares parameters, resources and outputs of the deployed template with the template, deployed is nil
+// when there is no deployed stack. Both are compared as YAML with sorted keys and scalars converted to strings,
+// so formatting, key order and scalar types, e.g. 80 and "80", make no difference
+func Diff(deployed *packager.Template, template *packager.Template) ([]*Change, error) {
+	oldSections, err := sections(deployed)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while converting deployed template")
+	}
+
+	newSections, err := sections(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while converting template")
+	}
+
+	changes := []*Change{}
+
+	for _, section := range Sections {
+		oldEntities, newEntities := oldSections[section], newSections[section]
+
+		for _, logicalID := range logicalIDs(oldEntities, newEntities) {
+			oldValue, oldOk := oldEntities[logicalID]
+			newValue, newOk := newEntities[logicalID]
+
+			oldLines, err := render(oldValue, oldOk)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error while rendering %s.%s", section, logicalID)
+			}
+
+			newLines, err := render(newValue, newOk)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error while rendering %s.%s", section, logicalID)
+			}
+
+			change := &Change{
+				Section:   section,
+				LogicalId: logicalID,
+				Action:    cloudformation.ChangeActionModify,
+				Hunks:     hunks(diffLines(oldLines, newLines)),
+			}
+
+			switch {
+			case len(change.Hunks) == 0:
+				continue
+			case !oldOk:
+				change.Action = cloudformation.ChangeActionAdd
+			case !newOk:
+				change.Action = cloudformation.ChangeActionRemove
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// UnifiedFormatter returns a formatter that writes changes as unified diff,
+// removed and added lines are colour-coded when colored is true
+func UnifiedFormatter(colored bool) writer.FormatFunc {
+	return func(wr io.Writer, message interface{}) {
+		changes, ok := message.([]*Change)
+		if !ok {
+			writer.PlainFormatter(wr, message)
+			return
+		}
+
+		Write(wr, changes, colored)
+	}
+}
+
+// Write writes changes as unified diff of the deployed template and the template, each hunk header
+// names the parameter, resource or output, nothing is written when there are no changes
+func Write(wr io.Writer, changes []*Change, colored bool) {
+	if len(changes) == 0 {
+		return
+	}
+
+	writeLine(wr, "--- deployed", writer.ColorRed, colored)
+	writeLine(wr, "+++ template", writer.ColorGreen, colored)
+
+	for _, c := range changes {
+		for _, h := range c.Hunks {
+			header := fmt.Sprintf("@@ -%d,%d +%d,%d @@ %s.%s", h.OldStart, h.OldLines, h.NewStart, h.NewLines, c.Section, c.LogicalId)
+			writeLine(wr, header, writer.ColorCyan, colored)
+
+			for _, line := range h.Lines {
+				color := ""
+
+				switch line[0] {
+				case '-':
+					color = writer.ColorRed
+				case '+':
+					color = writer.ColorGreen
+				}
+
+				writeLine(wr, line, color, colored)
+			}
+		}
+	}
+}
+
+func writeLine(wr io.Writer, line string, color string, colored bool) {
+	if colored && color != "" {
+		line = writer.Colorize(line, color)
+	}
+
+	fmt.Fprintln(wr, line)
+}
+
+// sections converts the template into generic sections keyed by their names
+func sections(template *packager.Template) (map[string]map[string]interface{}, error) {
+	generic := map[string]map[string]interface{}{}
+
+	if template == nil {
+		return generic, nil
+	}
+
+	raw, err := json.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	for _, section := range Sections {
+		entities, _ := all[section].(map[string]interface{})
+		generic[section] = entities
+	}
+
+	return generic, nil
+}
+
+func logicalIDs(oldEntities map[string]interface{}, newEntities map[string]interface{}) []string {
+	unique := map[string]bool{}
+
+	for logicalID := range oldEntities {
+		unique[logicalID] = true
+	}
+
+	for logicalID := range newEntities {
+		unique[logicalID] = true
+	}
+
+	ids := make([]string, 0, len(unique))
+	for logicalID := range unique {
+		ids = append(ids, logicalID)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// render renders the value as YAML lines, missing value has no lines
+func render(value interface{}, ok bool) ([]string, error) {
+	if !ok {
+		return []string{}, nil
+	}
+
+	raw, err := yaml.Marshal(normalise(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n"), nil
+}
+
+// normalise converts numbers and booleans into strings, CloudFormation treats them the same
+func normalise(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = normalise(item)
+		}
+
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalise(item)
+		}
+
+		return items
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return value
+}
+
+// diffLines returns the shortest edit script turning old lines into new lines, each line is prefixed
+// with space when it's unchanged, - when it's removed and + when it's added
+func diffLines(oldLines []string, newLines []string) []string {
+	n, m := len(oldLines), len(newLines)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case oldLines[i] == newLines[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, " "+oldLines[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+oldLines[i])
+			i++
+		default:
+			lines = append(lines, "+"+newLines[j])
+			j++
+		}
+	}
+
+	for ; i < n; i++ {
+		lines = append(lines, "-"+oldLines[i])
+	}
+
+	for ; j < m; j++ {
+		lines = append(lines, "+"+newLines[j])
+	}
+
+	return lines
+}
+
+// hunks groups changed lines of the edit script into hunks, changes separated by no more than
+// twice the number of context lines share the hunk
+func hunks(lines []string) []*Hunk {
+	oldLine := make([]int, len(lines))
+	newLine := make([]int, len(lines))
+
+	for i, o, n := 0, 1, 1; i < len(lines); i++ {
+		oldLine[i], newLine[i] = o, n
+
+		if lines[i][0] != '+' {
+			o++
+		}
+
+		if lines[i][0] != '-' {
+			n++
+		}
+	}
+
+	result := []*Hunk{}
+
+	for i := 0; i < len(lines); {
+		if lines[i][0] == ' ' {
+			i++
+			continue
+		}
+
+		last := i
+		for j := i; j < len(lines) && j-last <= 2*contextLines; j++ {
+			if lines[j][0] != ' ' {
+				last = j
+			}
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		stop := last + contextLines + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		h := &Hunk{OldStart: oldLine[start], NewStart: newLine[start], Lines: lines[start:stop]}
+
+		for _, line := range h.Lines {
+			if line[0] != '+' {
+				h.OldLines++
+			}
+
+			if line[0] != '-' {
+				h.NewLines++
+			}
+		}
+
+		// empty side starts at the line before the hunk as in unified diff
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+
+		result = append(result, h)
+		i = stop
+	}
+
+	return result
+}
@@ -0,0 +1,197 @@
+package templatediff_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/packager"
+	"github.com/b-b3rn4rd/gocfn/pkg/templatediff"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const deployedBody = `
+Parameters:
+  Env:
+    Type: String
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${Env}-bucket"
+      VersioningConfiguration:
+        Status: Enabled
+  Queue:
+    Type: AWS::SQS::Queue
+Outputs:
+  Port:
+    Value: 80
+`
+
+const templateBody = `{
+  "Parameters": {"Env": {"Type": "String"}},
+  "Resources": {
+    "Bucket": {
+      "Type": "AWS::S3::Bucket",
+      "Properties": {
+        "VersioningConfiguration": {"Status": "Enabled"},
+        "BucketName": {"Fn::Sub": "${Env}-data"}
+      }
+    },
+    "Topic": {"Type": "AWS::SNS::Topic"}
+  },
+  "Outputs": {"Port": {"Value": "80"}}
+}`
+
+func parse(t *testing.T, body string) *packager.Template {
+	logger, _ := test.NewNullLogger()
+
+	template, err := packager.New(logger, afero.NewMemMapFs()).Parse([]byte(body))
+	assert.NoError(t, err)
+
+	return template
+}
+
+func items(changed map[int]string) string {
+	lines := []string{"Resources:", "  List:", "    Type: Custom::List", "    Properties:", "      Items:"}
+
+	for i := 1; i <= 20; i++ {
+		item := fmt.Sprintf("item%d", i)
+		if value, ok := changed[i]; ok {
+			item = value
+		}
+
+		lines = append(lines, "        - "+item)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		deployed        *packager.Template
+		template        *packager.Template
+		expectedChanges []*templatediff.Change
+	}{
+		"diff lists modified, removed and added resources": {
+			deployed: parse(t, deployedBody),
+			template: parse(t, templateBody),
+			expectedChanges: []*templatediff.Change{
+				{
+					Section:   "Resources",
+					LogicalId: "Bucket",
+					Action:    "Modify",
+					Hunks: []*templatediff.Hunk{
+						{
+							OldStart: 1, OldLines: 6, NewStart: 1, NewLines: 6,
+							Lines: []string{
+								" Properties:",
+								"   BucketName:",
+								"-    Fn::Sub: ${Env}-bucket",
+								"+    Fn::Sub: ${Env}-data",
+								"   VersioningConfiguration:",
+								"     Status: Enabled",
+								" Type: AWS::S3::Bucket",
+							},
+						},
+					},
+				},
+				{
+					Section:   "Resources",
+					LogicalId: "Queue",
+					Action:    "Remove",
+					Hunks: []*templatediff.Hunk{
+						{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []string{"-Type: AWS::SQS::Queue"}},
+					},
+				},
+				{
+					Section:   "Resources",
+					LogicalId: "Topic",
+					Action:    "Add",
+					Hunks: []*templatediff.Hunk{
+						{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: []string{"+Type: AWS::SNS::Topic"}},
+					},
+				},
+			},
+		},
+		"diff adds everything when there is no deployed template": {
+			template: parse(t, "Resources:\n  Topic:\n    Type: AWS::SNS::Topic\n"),
+			expectedChanges: []*templatediff.Change{
+				{
+					Section:   "Resources",
+					LogicalId: "Topic",
+					Action:    "Add",
+					Hunks: []*templatediff.Hunk{
+						{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: []string{"+Type: AWS::SNS::Topic"}},
+					},
+				},
+			},
+		},
+		"diff splits distant changes into hunks": {
+			deployed: parse(t, items(nil)),
+			template: parse(t, items(map[int]string{2: "changed2", 19: "changed19"})),
+			expectedChanges: []*templatediff.Change{
+				{
+					Section:   "Resources",
+					LogicalId: "List",
+					Action:    "Modify",
+					Hunks: []*templatediff.Hunk{
+						{
+							OldStart: 1, OldLines: 7, NewStart: 1, NewLines: 7,
+							Lines: []string{" Properties:", "   Items:", "   - item1", "-  - item2", "+  - changed2", "   - item3", "   - item4", "   - item5"},
+						},
+						{
+							OldStart: 18, OldLines: 6, NewStart: 18, NewLines: 6,
+							Lines: []string{"   - item16", "   - item17", "   - item18", "-  - item19", "+  - changed19", "   - item20", " Type: Custom::List"},
+						},
+					},
+				},
+			},
+		},
+		"diff is empty when templates are the same": {
+			deployed:        parse(t, deployedBody),
+			template:        parse(t, deployedBody),
+			expectedChanges: []*templatediff.Change{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changes, err := templatediff.Diff(test.deployed, test.template)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedChanges, changes)
+		})
+	}
+}
+
+func TestUnifiedFormatter(t *testing.T) {
+	changes, err := templatediff.Diff(parse(t, deployedBody), parse(t, templateBody))
+	assert.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	templatediff.UnifiedFormatter(false)(out, changes)
+
+	assert.Equal(t, "--- deployed\n"+
+		"+++ template\n"+
+		"@@ -1,6 +1,6 @@ Resources.Bucket\n"+
+		" Properties:\n"+
+		"   BucketName:\n"+
+		"-    Fn::Sub: ${Env}-bucket\n"+
+		"+    Fn::Sub: ${Env}-data\n"+
+		"   VersioningConfiguration:\n"+
+		"     Status: Enabled\n"+
+		" Type: AWS::S3::Bucket\n"+
+		"@@ -1,1 +0,0 @@ Resources.Queue\n"+
+		"-Type: AWS::SQS::Queue\n"+
+		"@@ -0,0 +1,1 @@ Resources.Topic\n"+
+		"+Type: AWS::SNS::Topic\n", out.String())
+
+	out.Reset()
+	templatediff.UnifiedFormatter(false)(out, []*templatediff.Change{})
+
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,41 @@
+package timings
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/writer"
+)
+
+// TableFormatter writes report as tables of resources, the critical path and the slowest resources
+func TableFormatter(wr io.Writer, message interface{}) {
+	report, ok := message.(*Report)
+	if !ok {
+		writer.PlainFormatter(wr, message)
+		return
+	}
+
+	fmt.Fprintf(wr, "Stack %s operation took %s\n", report.StackName, report.Duration().Round(time.Second))
+
+	writeTable(wr, "Resources", report.Resources)
+	writeTable(wr, "Critical path", report.CriticalPath)
+	writeTable(wr, "Slowest resources", report.Slowest)
+}
+
+func writeTable(wr io.Writer, title string, timings []*Timing) {
+	fmt.Fprintf(wr, "\n%s:\n", title)
+
+	for _, t := range timings {
+		line := fmt.Sprintf("  %-10s  %-30s  %-40s  %-20s  %s",
+			t.Duration().Round(time.Second),
+			t.LogicalResourceId,
+			t.ResourceType,
+			t.ResourceStatus,
+			t.StartTime.UTC().Format(time.RFC3339),
+		)
+
+		fmt.Fprintln(wr, strings.TrimRight(line, " "))
+	}
+}
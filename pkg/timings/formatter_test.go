@@ -0,0 +1,30 @@
+package timings_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/b-b3rn4rd/gocfn/pkg/timings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableFormatter(t *testing.T) {
+	bucket := timing("Bucket", "UPDATE_COMPLETE", time.Second, time.Minute*2)
+
+	out := &bytes.Buffer{}
+	timings.TableFormatter(out, &timings.Report{
+		StackName:    "hello",
+		Seconds:      600,
+		Resources:    []*timings.Timing{bucket},
+		CriticalPath: []*timings.Timing{bucket},
+		Slowest:      []*timings.Timing{},
+	})
+
+	line := "  1m59s       Bucket                          AWS::SQS::Queue                           UPDATE_COMPLETE       2018-03-25T07:12:53Z\n"
+
+	assert.Equal(t, "Stack hello operation took 10m0s\n"+
+		"\nResources:\n"+line+
+		"\nCritical path:\n"+line+
+		"\nSlowest resources:\n", out.String())
+}
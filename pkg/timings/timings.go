@@ -0,0 +1,168 @@
+package timings
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+)
+
+// Timing time a resource has spent in a single *_IN_PROGRESS status until it
+// reached the matching *_COMPLETE or *_FAILED status
+type Timing struct {
+	LogicalResourceId string
+	ResourceType      string
+	ResourceStatus    string
+	StartTime         time.Time
+	EndTime           time.Time
+	Seconds           float64
+}
+
+// Report timings of a stack operation. Stack events don't carry dependencies between
+// resources, so CriticalPath is inferred as the longest chain of timings where each
+// timing starts after the previous one has finished
+type Report struct {
+	StackName    string
+	Seconds      float64
+	Resources    []*Timing
+	CriticalPath []*Timing
+	Slowest      []*Timing
+}
+
+// Duration time the resource has spent in progress
+func (t *Timing) Duration() time.Duration {
+	return t.EndTime.Sub(t.StartTime)
+}
+
+// Duration time between the first and the last event of the operation
+func (r *Report) Duration() time.Duration {
+	return time.Duration(r.Seconds * float64(time.Second))
+}
+
+// Analyze pairs events of each resource into timings, stackEvents must be events of
+// a single operation in chronological order. Resources which are still in progress
+// are left out, Slowest contains at most slowest timings and none when slowest is negative
+func Analyze(stackName string, stackEvents streamer.StackEventList, slowest int) *Report {
+	report := &Report{
+		StackName:    stackName,
+		Resources:    []*Timing{},
+		CriticalPath: []*Timing{},
+		Slowest:      []*Timing{},
+	}
+
+	if len(stackEvents) == 0 {
+		return report
+	}
+
+	first := aws.TimeValue(stackEvents[0].Timestamp)
+	last := aws.TimeValue(stackEvents[len(stackEvents)-1].Timestamp)
+	report.Seconds = last.Sub(first).Seconds()
+
+	inProgress := map[string]*Timing{}
+
+	for _, e := range stackEvents {
+		if isStack(e) {
+			continue
+		}
+
+		logicalID := aws.StringValue(e.LogicalResourceId)
+		status := aws.StringValue(e.ResourceStatus)
+
+		switch {
+		case strings.HasSuffix(status, "_IN_PROGRESS"):
+			// replacements report IN_PROGRESS twice, the timing starts at the first one
+			if _, exists := inProgress[logicalID]; !exists {
+				inProgress[logicalID] = &Timing{
+					LogicalResourceId: logicalID,
+					ResourceType:      aws.StringValue(e.ResourceType),
+					StartTime:         aws.TimeValue(e.Timestamp),
+				}
+			}
+		case strings.HasSuffix(status, "_COMPLETE"), strings.HasSuffix(status, "_FAILED"):
+			t, exists := inProgress[logicalID]
+			if !exists {
+				continue
+			}
+
+			delete(inProgress, logicalID)
+
+			t.ResourceStatus = status
+			t.EndTime = aws.TimeValue(e.Timestamp)
+			t.Seconds = t.Duration().Seconds()
+			report.Resources = append(report.Resources, t)
+		}
+	}
+
+	sort.SliceStable(report.Resources, func(i, j int) bool {
+		return report.Resources[i].StartTime.Before(report.Resources[j].StartTime)
+	})
+
+	report.CriticalPath = criticalPath(report.Resources)
+	report.Slowest = slowestTimings(report.Resources, slowest)
+
+	return report
+}
+
+// criticalPath walks back from the timing finishing last, picking the timing which
+// finished last before the current one has started. Zero-length timings sharing a
+// timestamp precede each other, timings already on the path are skipped
+func criticalPath(timings []*Timing) []*Timing {
+	path := []*Timing{}
+	onPath := map[*Timing]bool{}
+
+	var current *Timing
+
+	for _, t := range timings {
+		if current == nil || t.EndTime.After(current.EndTime) {
+			current = t
+		}
+	}
+
+	for current != nil {
+		path = append([]*Timing{current}, path...)
+		onPath[current] = true
+
+		var previous *Timing
+
+		for _, t := range timings {
+			if onPath[t] || t.EndTime.After(current.StartTime) {
+				continue
+			}
+
+			if previous == nil || t.EndTime.After(previous.EndTime) {
+				previous = t
+			}
+		}
+
+		current = previous
+	}
+
+	return path
+}
+
+func slowestTimings(timings []*Timing, slowest int) []*Timing {
+	sorted := make([]*Timing, len(timings))
+	copy(sorted, timings)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration() > sorted[j].Duration()
+	})
+
+	if slowest < 0 {
+		slowest = 0
+	}
+
+	if len(sorted) > slowest {
+		sorted = sorted[:slowest]
+	}
+
+	return sorted
+}
+
+// isStack checks if event belongs to the stack itself rather than to its resource
+func isStack(e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" && aws.StringValue(e.PhysicalResourceId) == aws.StringValue(e.StackId)
+}
@@ -0,0 +1,108 @@
+package timings_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/b-b3rn4rd/gocfn/internal/streamertest"
+	"github.com/b-b3rn4rd/gocfn/pkg/streamer"
+	"github.com/b-b3rn4rd/gocfn/pkg/timings"
+	"github.com/stretchr/testify/assert"
+)
+
+func timing(logicalID string, status string, start time.Duration, end time.Duration) *timings.Timing {
+	return &timings.Timing{
+		LogicalResourceId: logicalID,
+		ResourceType:      "AWS::SQS::Queue",
+		ResourceStatus:    status,
+		StartTime:         streamertest.Timestamp.Add(start),
+		EndTime:           streamertest.Timestamp.Add(end),
+		Seconds:           (end - start).Seconds(),
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	stackStarted := streamertest.StackEvent("arn:hello", "hello", "arn:hello", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "", 0)
+	stackCompleted := streamertest.StackEvent("arn:hello", "hello", "arn:hello", "AWS::CloudFormation::Stack", "UPDATE_COMPLETE", "", time.Minute*10)
+
+	role := timing("Role", "CREATE_COMPLETE", time.Second, time.Minute)
+	bucket := timing("Bucket", "UPDATE_COMPLETE", time.Second, time.Minute*2)
+	function := timing("Function", "CREATE_FAILED", time.Minute+time.Second, time.Minute*5)
+	deletedBucket := timing("Bucket", "DELETE_COMPLETE", time.Minute*9, time.Minute*9+time.Second*30)
+	queue := timing("Queue", "CREATE_COMPLETE", time.Second, time.Second)
+	topic := timing("Topic", "CREATE_COMPLETE", time.Second, time.Second)
+
+	tests := map[string]struct {
+		stackEvents  streamer.StackEventList
+		duration     time.Duration
+		resources    []*timings.Timing
+		criticalPath []*timings.Timing
+		slowest      []*timings.Timing
+	}{
+		"timings are paired and chained into the critical path": {
+			stackEvents: streamer.StackEventList{
+				stackStarted,
+				streamertest.StackEvent("arn:hello", "Role", "Role", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::SQS::Queue", "UPDATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::SQS::Queue", "UPDATE_IN_PROGRESS", "", time.Second*2),
+				streamertest.StackEvent("arn:hello", "Role", "Role", "AWS::SQS::Queue", "CREATE_COMPLETE", "", time.Minute),
+				streamertest.StackEvent("arn:hello", "Function", "Function", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Minute+time.Second),
+				streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::SQS::Queue", "UPDATE_COMPLETE", "", time.Minute*2),
+				streamertest.StackEvent("arn:hello", "Function", "Function", "AWS::SQS::Queue", "CREATE_FAILED", "", time.Minute*5),
+				streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::SQS::Queue", "DELETE_IN_PROGRESS", "", time.Minute*9),
+				streamertest.StackEvent("arn:hello", "Bucket", "Bucket", "AWS::SQS::Queue", "DELETE_COMPLETE", "", time.Minute*9+time.Second*30),
+				streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Minute*9+time.Second*40),
+				stackCompleted,
+			},
+			duration:     time.Minute * 10,
+			resources:    []*timings.Timing{role, bucket, function, deletedBucket},
+			criticalPath: []*timings.Timing{role, function, deletedBucket},
+			slowest:      []*timings.Timing{function, bucket},
+		},
+		"zero-length timings sharing a timestamp are on the critical path once": {
+			stackEvents: streamer.StackEventList{
+				streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("arn:hello", "Topic", "Topic", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Second),
+				streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_COMPLETE", "", time.Second),
+				streamertest.StackEvent("arn:hello", "Topic", "Topic", "AWS::SQS::Queue", "CREATE_COMPLETE", "", time.Second),
+			},
+			duration:     0,
+			resources:    []*timings.Timing{queue, topic},
+			criticalPath: []*timings.Timing{topic, queue},
+			slowest:      []*timings.Timing{queue, topic},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := timings.Analyze("hello", test.stackEvents, 2)
+
+			assert.Equal(t, "hello", report.StackName)
+			assert.Equal(t, test.duration, report.Duration())
+			assert.Equal(t, test.resources, report.Resources)
+			assert.Equal(t, test.criticalPath, report.CriticalPath)
+			assert.Equal(t, test.slowest, report.Slowest)
+		})
+	}
+}
+
+func TestAnalyzeWithNegativeSlowest(t *testing.T) {
+	report := timings.Analyze("hello", streamer.StackEventList{
+		streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_IN_PROGRESS", "", time.Second),
+		streamertest.StackEvent("arn:hello", "Queue", "Queue", "AWS::SQS::Queue", "CREATE_COMPLETE", "", time.Minute),
+	}, -1)
+
+	assert.Len(t, report.Resources, 1)
+	assert.Equal(t, []*timings.Timing{}, report.Slowest)
+}
+
+func TestAnalyzeWithoutEvents(t *testing.T) {
+	report := timings.Analyze("hello", streamer.StackEventList{}, 10)
+
+	assert.Equal(t, &timings.Report{
+		StackName:    "hello",
+		Resources:    []*timings.Timing{},
+		CriticalPath: []*timings.Timing{},
+		Slowest:      []*timings.Timing{},
+	}, report)
+}
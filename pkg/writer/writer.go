@@ -6,6 +6,15 @@ import (
 	"io"
 )
 
+// ANSI escape codes of colours used by formatters
+const (
+	ColorReset  = "\x1b[0m"
+	ColorRed    = "\x1b[31m"
+	ColorGreen  = "\x1b[32m"
+	ColorYellow = "\x1b[33m"
+	ColorCyan   = "\x1b[36m"
+)
+
 type FormatFunc func(wr io.Writer, message interface{})
 
 type StringWriter struct {
@@ -32,3 +41,14 @@ func JSONFormatter(wr io.Writer, message interface{}) {
 	raw, _ := json.MarshalIndent(message, "", "    ")
 	fmt.Fprintln(wr, string(raw))
 }
+
+// NDJSONFormatter writes message as a single line of JSON
+func NDJSONFormatter(wr io.Writer, message interface{}) {
+	raw, _ := json.Marshal(message)
+	fmt.Fprintln(wr, string(raw))
+}
+
+// Colorize wraps text in the colour escape code and resets the colour after it
+func Colorize(text string, color string) string {
+	return color + text + ColorReset
+}
@@ -44,3 +44,20 @@ func TestPlainFormatter(t *testing.T) {
 	writer.PlainFormatter(out, expectedMessage)
 	assert.Equal(t, out.String(), fmt.Sprintf("%s\n", expectedMessage))
 }
+
+func TestNDJSONFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	message := struct {
+		Text string
+	}{"hello world"}
+
+	writer.NDJSONFormatter(out, message)
+	writer.NDJSONFormatter(out, message)
+
+	assert.Equal(t, "{\"Text\":\"hello world\"}\n{\"Text\":\"hello world\"}\n", out.String())
+}
+
+func TestColorize(t *testing.T) {
+	assert.Equal(t, "\x1b[31mhello world\x1b[0m", writer.Colorize("hello world", writer.ColorRed))
+}